@@ -0,0 +1,441 @@
+package Admin
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jonathanpatta/apartmentservices/Consumers"
+	"github.com/jonathanpatta/apartmentservices/Flags"
+	"github.com/jonathanpatta/apartmentservices/Items"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Orders"
+	"github.com/jonathanpatta/apartmentservices/Producers"
+	"github.com/jonathanpatta/apartmentservices/Services"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Subscriptions"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+)
+
+const AuditPrefix = "AUDIT#"
+const SuspensionPrefix = "SUSPENSION#"
+
+// AuditEntry records a single moderation action taken by an admin.
+type AuditEntry struct {
+	Utils.Meta
+	ActorUserId string `json:"actor_user_id,omitempty"`
+	ActorName   string `json:"actor_name,omitempty"`
+	Action      string `json:"action,omitempty"`
+	TargetId    string `json:"target_id,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// Suspension blocks a user from the api while it exists.
+//
+// The SK is the suspended user id followed by the suspended record, so a
+// user with a suspended consumer and producer has two of them.
+type Suspension struct {
+	Utils.Meta
+	UserId   string `json:"user_id,omitempty"`
+	TargetId string `json:"target_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type ModerationInput struct {
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// ListFilter narrows down an entity listing.
+type ListFilter struct {
+	// Parent restricts the listing to records whose id starts with it, e.g.
+	// the orders of one consumer.
+	Parent          string
+	UserId          string
+	CreatedByUserId string
+	ItemId          string
+	IncludeDeleted  bool
+}
+
+type entity struct {
+	prefix string
+	decode func([]map[string]types.AttributeValue) (interface{}, error)
+}
+
+// decodeInto returns a decoder that unmarshals into the slice newData
+// returns a pointer to.
+func decodeInto(newData func() interface{}) func([]map[string]types.AttributeValue) (interface{}, error) {
+	return func(items []map[string]types.AttributeValue) (interface{}, error) {
+		data := newData()
+		err := attributevalue.UnmarshalListOfMaps(items, data)
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+}
+
+var entities = map[string]entity{
+	"consumers": {Consumers.ConsumerPrefix, decodeInto(func() interface{} {
+		return &[]*Consumers.Consumer{}
+	})},
+	"producers": {Producers.ProducerPrefix, decodeInto(func() interface{} {
+		return &[]*Producers.Producer{}
+	})},
+	"services": {Services.ServicePrefix, decodeInto(func() interface{} {
+		return &[]*Services.Service{}
+	})},
+	"items": {Items.ItemPrefix, decodeInto(func() interface{} {
+		return &[]*Items.Item{}
+	})},
+	"orders": {Orders.OrderPrefix, decodeInto(func() interface{} {
+		return &[]*Orders.Order{}
+	})},
+	"subscriptions": {Subscriptions.SubscriptionPrefix, decodeInto(func() interface{} {
+		return &[]*Subscriptions.Subscription{}
+	})},
+	"audit": {AuditPrefix, decodeInto(func() interface{} {
+		return &[]*AuditEntry{}
+	})},
+	"flags": {Flags.FlagPrefix, decodeInto(func() interface{} {
+		return &[]*Flags.Flag{}
+	})},
+}
+
+type AdminService struct {
+	db               *dynamodb.Client
+	dynamodbSettings *Settings.DynamoDbSettings
+	consumersCli     *Consumers.ConsumerService
+	producersCli     *Producers.ProducerService
+	itemsCli         *Items.ItemService
+	flags            *Flags.FlagService
+	auth             *Middleware.MiddlwareService
+}
+
+func NewAdminService(settings *Settings.Settings) (*AdminService, error) {
+	consumersCli, err := Consumers.NewConsumerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	producersCli, err := Producers.NewProducerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	itemsCli, err := Items.NewItemService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AdminService{
+		db:               settings.Dynamo.Cli,
+		dynamodbSettings: settings.Dynamo,
+		consumersCli:     consumersCli,
+		producersCli:     producersCli,
+		itemsCli:         itemsCli,
+		flags:            settings.Flags,
+		auth:             settings.MiddlewareService,
+	}, nil
+}
+
+// List returns every record of the named entity matching the filter,
+// including suspended and hidden ones.
+func (s *AdminService) List(entityName string, in *ListFilter) (interface{}, error) {
+	e, ok := entities[entityName]
+	if !ok {
+		return nil, Utils.NewError(Utils.NotFound, "unknown entity %v", entityName)
+	}
+
+	keyFilter := expression.Key("PK").Equal(expression.Value(e.prefix))
+	if in.Parent != "" {
+		keyFilter = keyFilter.And(expression.Key("SK").BeginsWith(in.Parent))
+	}
+	builder := expression.NewBuilder().WithKeyCondition(keyFilter)
+
+	var conditions []expression.ConditionBuilder
+	if !in.IncludeDeleted {
+		conditions = append(conditions, expression.Name("IsDeleted").NotEqual(expression.Value(true)))
+	}
+	if in.UserId != "" {
+		conditions = append(conditions, expression.Name("UserId").Equal(expression.Value(in.UserId)))
+	}
+	if in.CreatedByUserId != "" {
+		conditions = append(conditions, expression.Name("CreatedByUserId").Equal(expression.Value(in.CreatedByUserId)))
+	}
+	if in.ItemId != "" {
+		conditions = append(conditions, expression.Name("ItemId").Equal(expression.Value(in.ItemId)))
+	}
+	if len(conditions) == 1 {
+		builder = builder.WithFilter(conditions[0])
+	} else if len(conditions) > 1 {
+		builder = builder.WithFilter(expression.And(conditions[0], conditions[1], conditions[2:]...))
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(s.db, &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+	})
+
+	var items []map[string]types.AttributeValue
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, out.Items...)
+	}
+
+	return e.decode(items)
+}
+
+func (s *AdminService) SuspendProducer(actor *Middleware.FirebaseUser, producerId string, in *ModerationInput) (*Producers.Producer, error) {
+	producer, err := s.producersCli.SetSuspended(producerId, true)
+	if err != nil {
+		return nil, err
+	}
+	err = s.suspend(producer.UserId, producer.SK, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit(actor, "suspend_producer", producer.SK, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return producer, nil
+}
+
+func (s *AdminService) ReinstateProducer(actor *Middleware.FirebaseUser, producerId string, in *ModerationInput) (*Producers.Producer, error) {
+	producer, err := s.producersCli.SetSuspended(producerId, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.reinstate(producer.UserId, producer.SK)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit(actor, "reinstate_producer", producer.SK, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return producer, nil
+}
+
+func (s *AdminService) SuspendConsumer(actor *Middleware.FirebaseUser, consumerId string, in *ModerationInput) (*Consumers.Consumer, error) {
+	consumer, err := s.consumersCli.SetSuspended(consumerId, true)
+	if err != nil {
+		return nil, err
+	}
+	err = s.suspend(consumer.UserId, consumer.SK, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit(actor, "suspend_consumer", consumer.SK, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return consumer, nil
+}
+
+func (s *AdminService) ReinstateConsumer(actor *Middleware.FirebaseUser, consumerId string, in *ModerationInput) (*Consumers.Consumer, error) {
+	consumer, err := s.consumersCli.SetSuspended(consumerId, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.reinstate(consumer.UserId, consumer.SK)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit(actor, "reinstate_consumer", consumer.SK, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return consumer, nil
+}
+
+func (s *AdminService) SetItemHidden(actor *Middleware.FirebaseUser, itemId string, hidden bool, in *ModerationInput) (*Items.Item, error) {
+	item, err := s.itemsCli.SetHidden(itemId, hidden)
+	if err != nil {
+		return nil, err
+	}
+	action := "unhide_item"
+	if hidden {
+		action = "hide_item"
+	}
+	err = s.audit(actor, action, item.SK, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *AdminService) PutFlag(actor *Middleware.FirebaseUser, name string, in *Flags.FlagInput) (*Flags.Flag, error) {
+	flag, err := s.flags.Put(name, in)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit(actor, "put_flag", flag.SK, "")
+	if err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+func (s *AdminService) SetFlagEnabled(actor *Middleware.FirebaseUser, name string, enabled bool, in *ModerationInput) (*Flags.Flag, error) {
+	flag, err := s.flags.SetEnabled(name, enabled)
+	if err != nil {
+		return nil, err
+	}
+	action := "disable_flag"
+	if enabled {
+		action = "enable_flag"
+	}
+	err = s.audit(actor, action, flag.SK, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+func (s *AdminService) DeleteFlag(actor *Middleware.FirebaseUser, name string, in *ModerationInput) (*Flags.Flag, error) {
+	flag, err := s.flags.Delete(name)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit(actor, "delete_flag", flag.SK, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+// SuspensionChecker looks up the Suspensions of users for the auth
+// middleware.
+type SuspensionChecker struct {
+	db               *dynamodb.Client
+	dynamodbSettings *Settings.DynamoDbSettings
+}
+
+func NewSuspensionChecker(settings *Settings.Settings) *SuspensionChecker {
+	return &SuspensionChecker{
+		db:               settings.Dynamo.Cli,
+		dynamodbSettings: settings.Dynamo,
+	}
+}
+
+// EnforceSuspensions makes the auth middleware of settings reject suspended
+// users. Every entry point calls it before serving requests.
+func EnforceSuspensions(settings *Settings.Settings) {
+	settings.MiddlewareService.SetSuspensionChecker(NewSuspensionChecker(settings), settings.Config.SuspensionCacheTTL)
+}
+
+// IsSuspended reports whether any record linked to the user is suspended.
+func (s *SuspensionChecker) IsSuspended(userId string) (bool, error) {
+	if userId == "" {
+		return false, nil
+	}
+
+	keyFilter := expression.Key("PK").Equal(expression.Value(SuspensionPrefix)).
+		And(expression.Key("SK").BeginsWith(userId + "_"))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).Build()
+	if err != nil {
+		return false, err
+	}
+
+	out, err := s.db.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+		Limit:                     aws.Int32(1),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return out.Count > 0, nil
+}
+
+func (s *AdminService) suspend(userId string, targetId string, reason string) error {
+	if userId == "" {
+		return Utils.NewError(Utils.Conflict, "record is not linked to a user")
+	}
+
+	suspension := &Suspension{
+		UserId:   userId,
+		TargetId: targetId,
+		Reason:   reason,
+	}
+	suspension.PK = SuspensionPrefix
+	suspension.SK = userId + "_" + targetId
+	suspension.SetCreatedAtNow()
+	suspension.SetLastModifiedNow()
+
+	item, err := attributevalue.MarshalMap(suspension)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	if err != nil {
+		return err
+	}
+	s.auth.ForgetSuspension(userId)
+	return nil
+}
+
+func (s *AdminService) reinstate(userId string, targetId string) error {
+	key, err := attributevalue.MarshalMap(map[string]string{
+		"PK": SuspensionPrefix,
+		"SK": userId + "_" + targetId,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+		Key:       key,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	if err != nil {
+		return err
+	}
+	s.auth.ForgetSuspension(userId)
+	return nil
+}
+
+func (s *AdminService) audit(actor *Middleware.FirebaseUser, action string, targetId string, reason string) error {
+	entry := &AuditEntry{
+		ActorUserId: actor.UserId,
+		ActorName:   actor.Name,
+		Action:      action,
+		TargetId:    targetId,
+		Reason:      reason,
+	}
+	err := entry.New(AuditPrefix, targetId)
+	if err != nil {
+		return err
+	}
+
+	item, err := attributevalue.MarshalMap(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	return err
+}
@@ -0,0 +1,258 @@
+package Admin
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Flags"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"log"
+	"net/http"
+)
+
+type AdminHttpService struct {
+	service *AdminService
+}
+
+func NewAdminHttpService(settings *Settings.Settings) (*AdminHttpService, error) {
+	service, err := NewAdminService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AdminHttpService{
+		service: service,
+	}, nil
+}
+
+func writeJson(w http.ResponseWriter, r *http.Request, data interface{}) {
+	Middleware.WriteJson(w, r, http.StatusOK, data)
+}
+
+// decodeModerationInput reads the optional reason sent with a moderation
+// action.
+func decodeModerationInput(r *http.Request) (*ModerationInput, error) {
+	var data ModerationInput
+	if r.ContentLength == 0 {
+		return &data, nil
+	}
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (s *AdminHttpService) List(w http.ResponseWriter, r *http.Request) {
+	entityName := mux.Vars(r)["entity"]
+	query := r.URL.Query()
+
+	filter := &ListFilter{
+		Parent:          query.Get("parent"),
+		UserId:          query.Get("user_id"),
+		CreatedByUserId: query.Get("created_by_user_id"),
+		ItemId:          query.Get("item_id"),
+		IncludeDeleted:  query.Get("include_deleted") == "true",
+	}
+
+	data, err := s.service.List(entityName, filter)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	writeJson(w, r, data)
+}
+
+func (s *AdminHttpService) SuspendProducer(w http.ResponseWriter, r *http.Request) {
+	producerId := mux.Vars(r)["producerId"]
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	data, err := decodeModerationInput(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	producer, err := s.service.SuspendProducer(user, producerId, data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	writeJson(w, r, producer)
+}
+
+func (s *AdminHttpService) ReinstateProducer(w http.ResponseWriter, r *http.Request) {
+	producerId := mux.Vars(r)["producerId"]
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	data, err := decodeModerationInput(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	producer, err := s.service.ReinstateProducer(user, producerId, data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	writeJson(w, r, producer)
+}
+
+func (s *AdminHttpService) SuspendConsumer(w http.ResponseWriter, r *http.Request) {
+	consumerId := mux.Vars(r)["consumerId"]
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	data, err := decodeModerationInput(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	consumer, err := s.service.SuspendConsumer(user, consumerId, data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	writeJson(w, r, consumer)
+}
+
+func (s *AdminHttpService) ReinstateConsumer(w http.ResponseWriter, r *http.Request) {
+	consumerId := mux.Vars(r)["consumerId"]
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	data, err := decodeModerationInput(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	consumer, err := s.service.ReinstateConsumer(user, consumerId, data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	writeJson(w, r, consumer)
+}
+
+func (s *AdminHttpService) HideItem(w http.ResponseWriter, r *http.Request) {
+	s.setItemHidden(w, r, true)
+}
+
+func (s *AdminHttpService) UnhideItem(w http.ResponseWriter, r *http.Request) {
+	s.setItemHidden(w, r, false)
+}
+
+func (s *AdminHttpService) setItemHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	itemId := mux.Vars(r)["itemId"]
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	data, err := decodeModerationInput(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	item, err := s.service.SetItemHidden(user, itemId, hidden, data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	writeJson(w, r, item)
+}
+
+func (s *AdminHttpService) PutFlag(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	var data Flags.FlagInput
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	flag, err := s.service.PutFlag(user, name, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	writeJson(w, r, flag)
+}
+
+func (s *AdminHttpService) EnableFlag(w http.ResponseWriter, r *http.Request) {
+	s.setFlagEnabled(w, r, true)
+}
+
+func (s *AdminHttpService) DisableFlag(w http.ResponseWriter, r *http.Request) {
+	s.setFlagEnabled(w, r, false)
+}
+
+func (s *AdminHttpService) setFlagEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	name := mux.Vars(r)["name"]
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	data, err := decodeModerationInput(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	flag, err := s.service.SetFlagEnabled(user, name, enabled, data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	writeJson(w, r, flag)
+}
+
+func (s *AdminHttpService) DeleteFlag(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	data, err := decodeModerationInput(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	flag, err := s.service.DeleteFlag(user, name, data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	writeJson(w, r, flag)
+}
+
+func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
+	server, err := NewAdminHttpService(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	router := r.PathPrefix("/admin").Subrouter()
+
+	router.Use(settings.MiddlewareService.ValidateToken)
+	router.Use(Middleware.RequireRole(Middleware.AdminRole))
+
+	router.HandleFunc("/producer/{producerId}/suspend", server.SuspendProducer).Methods("POST", "OPTIONS")
+	router.HandleFunc("/producer/{producerId}/reinstate", server.ReinstateProducer).Methods("POST", "OPTIONS")
+	router.HandleFunc("/consumer/{consumerId}/suspend", server.SuspendConsumer).Methods("POST", "OPTIONS")
+	router.HandleFunc("/consumer/{consumerId}/reinstate", server.ReinstateConsumer).Methods("POST", "OPTIONS")
+	router.HandleFunc("/item/{itemId}/hide", server.HideItem).Methods("POST", "OPTIONS")
+	router.HandleFunc("/item/{itemId}/unhide", server.UnhideItem).Methods("POST", "OPTIONS")
+	router.HandleFunc("/flag/{name}", server.PutFlag).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/flag/{name}", server.DeleteFlag).Methods("DELETE")
+	router.HandleFunc("/flag/{name}/enable", server.EnableFlag).Methods("POST", "OPTIONS")
+	router.HandleFunc("/flag/{name}/disable", server.DisableFlag).Methods("POST", "OPTIONS")
+	router.HandleFunc("/{entity}", server.List).Methods("GET", "OPTIONS")
+}
@@ -0,0 +1,387 @@
+package ApiV2
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jonathanpatta/apartmentservices/Items"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Services"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"net/http"
+)
+
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// BulkRequest creates, updates and deletes many records of one producer.
+// Creates and updates are json records; updates carry the id of the record
+// and only the fields to change.
+type BulkRequest struct {
+	Create []json.RawMessage `json:"create" validate:"max=100"`
+	Update []json.RawMessage `json:"update" validate:"max=100"`
+	Delete []string          `json:"delete" validate:"max=100"`
+}
+
+// BulkResult reports one entry of a BulkRequest, in the order create, update,
+// delete. Index is the position of the entry within its list.
+type BulkResult struct {
+	Op     string                    `json:"op"`
+	Index  int                       `json:"index"`
+	Id     string                    `json:"id,omitempty"`
+	Status int                       `json:"status"`
+	Error  *Middleware.ErrorResponse `json:"error,omitempty"`
+	Data   interface{}               `json:"data,omitempty"`
+}
+
+type BulkResponse struct {
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// BulkItemFields are the fields of an item its producer sets. Hidden and the
+// record metadata are left to the service and to admins.
+type BulkItemFields struct {
+	Name        string   `json:"name,omitempty" validate:"required,max=100"`
+	Description string   `json:"description,omitempty" validate:"max=2000"`
+	ImageUrls   []string `json:"image_urls,omitempty" validate:"max=10"`
+	Price       int64    `json:"price,omitempty" validate:"min=0"`
+}
+
+func itemFieldsOf(item *Items.Item) BulkItemFields {
+	return BulkItemFields{
+		Name:        item.Name,
+		Description: item.Description,
+		ImageUrls:   item.ImageUrls,
+		Price:       item.Price,
+	}
+}
+
+// apply copies the fields onto item, like ItemService.Update.
+func (f *BulkItemFields) apply(item *Items.Item) {
+	item.Name = f.Name
+	item.Description = f.Description
+	item.ImageUrls = f.ImageUrls
+	item.Price = f.Price
+}
+
+// BulkItemCreate is an item created under ServiceId, or directly under the
+// producer when ServiceId is empty.
+type BulkItemCreate struct {
+	ServiceId string `json:"service_id,omitempty"`
+	BulkItemFields
+}
+
+type BulkItemUpdate struct {
+	Id string `json:"id" validate:"required"`
+	BulkItemFields
+}
+
+// BulkServiceFields are the fields of a service its producer sets.
+type BulkServiceFields struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type BulkServiceUpdate struct {
+	Id string `json:"id" validate:"required"`
+	BulkServiceFields
+}
+
+// bulkIds returns the ids of the updates and deletes of a request, skipping
+// updates without one.
+func bulkIds(data *BulkRequest) []string {
+	ids := append([]string{}, data.Delete...)
+	for _, raw := range data.Update {
+		if id := updateId(raw); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func updateId(raw json.RawMessage) string {
+	var entry struct {
+		Id string `json:"id"`
+	}
+	json.Unmarshal(raw, &entry)
+	return entry.Id
+}
+
+func decodeBulkEntry(raw json.RawMessage, v interface{}) error {
+	return Utils.DecodeAndValidate(bytes.NewReader(raw), v)
+}
+
+// bulkWriter collects the results of a bulk request and the records it
+// writes.
+type bulkWriter struct {
+	r       *http.Request
+	results []BulkResult
+	// pending maps each record waiting to be written to its result.
+	pending []int
+	// ids holds the ids of pending records, since one transaction cannot
+	// write the same record twice.
+	ids map[string]bool
+}
+
+func (b *bulkWriter) fail(op string, index int, id string, err error) {
+	status, resp := Middleware.NewErrorResponse(b.r, err)
+	resp.RequestId = ""
+	b.results = append(b.results, BulkResult{Op: op, Index: index, Id: id, Status: status, Error: &resp})
+}
+
+// write queues a successful entry whose record still has to be written. It
+// fails the entry instead, and reports false, when an earlier entry already
+// writes the same record.
+func (b *bulkWriter) write(op string, index int, id string, status int, data interface{}) bool {
+	if b.ids[id] {
+		b.fail(op, index, id, Utils.NewError(Utils.Conflict, "%v is changed by an earlier entry", id))
+		return false
+	}
+	b.ids[id] = true
+
+	b.pending = append(b.pending, len(b.results))
+	b.results = append(b.results, BulkResult{Op: op, Index: index, Id: id, Status: status, Data: data})
+	return true
+}
+
+// written applies the outcome of writing the pending records, given in the
+// order they were queued.
+func (b *bulkWriter) written(errs []error) *BulkResponse {
+	for i, err := range errs {
+		if err != nil {
+			result := &b.results[b.pending[i]]
+			status, resp := Middleware.NewErrorResponse(b.r, err)
+			resp.RequestId = ""
+			result.Status, result.Error, result.Data = status, &resp, nil
+		}
+	}
+
+	resp := &BulkResponse{Results: b.results}
+	for _, result := range b.results {
+		if result.Error == nil {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp
+}
+
+// BulkItems creates, updates and deletes items of a producer, writing them in
+// transactions. Each entry gets its own result, so one bad entry does not fail
+// the others, and an entry whose item changed since it was read gets a 412.
+func (s *V2HttpService) BulkItems(w http.ResponseWriter, r *http.Request) {
+	producer, err := s.producer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	var data BulkRequest
+	err = Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	existing, err := s.items.ReadMany(bulkIds(&data))
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	owned := func(itemId string) (*Items.Item, error) {
+		item, ok := existing[itemId]
+		if !ok || item.IsDeleted || !childOf(producer.SK, itemId) {
+			return nil, notFound("item", itemId)
+		}
+		return item, nil
+	}
+
+	creates := make([]BulkItemCreate, len(data.Create))
+	createErrs := make([]error, len(data.Create))
+	var serviceIds []string
+	for i, raw := range data.Create {
+		createErrs[i] = decodeBulkEntry(raw, &creates[i])
+		if createErrs[i] == nil && creates[i].ServiceId != "" {
+			serviceIds = append(serviceIds, creates[i].ServiceId)
+		}
+	}
+	services, err := s.services.ReadMany(serviceIds)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	b := &bulkWriter{r: r, ids: map[string]bool{}}
+	var records []*Items.Item
+	// versions holds the version each record is expected to be at, nil for
+	// the created ones.
+	var versions []*int64
+
+	for i := range creates {
+		if createErrs[i] != nil {
+			b.fail(BulkCreate, i, "", createErrs[i])
+			continue
+		}
+		var item Items.Item
+		creates[i].apply(&item)
+		parent := producer.SK
+		if serviceId := creates[i].ServiceId; serviceId != "" {
+			service, ok := services[serviceId]
+			if !ok || service.IsDeleted || !childOf(producer.SK, serviceId) {
+				b.fail(BulkCreate, i, "", notFound("service", serviceId))
+				continue
+			}
+			parent = service.SK
+		}
+		err = item.New(Items.ItemPrefix, parent)
+		if err != nil {
+			b.fail(BulkCreate, i, "", err)
+			continue
+		}
+		if b.write(BulkCreate, i, item.SK, http.StatusCreated, &item) {
+			records = append(records, &item)
+			versions = append(versions, nil)
+		}
+	}
+
+	for i, raw := range data.Update {
+		id := updateId(raw)
+		current, err := owned(id)
+		if id != "" && err != nil {
+			b.fail(BulkUpdate, i, id, err)
+			continue
+		}
+		entry := BulkItemUpdate{Id: id}
+		if current != nil {
+			entry.BulkItemFields = itemFieldsOf(current)
+		}
+		err = decodeBulkEntry(raw, &entry)
+		if err != nil {
+			b.fail(BulkUpdate, i, id, err)
+			continue
+		}
+		item := *current
+		entry.apply(&item)
+		item.SetLastModifiedNow()
+		if b.write(BulkUpdate, i, id, http.StatusOK, &item) {
+			records = append(records, &item)
+			versions = append(versions, &current.Version)
+		}
+	}
+
+	for i, id := range data.Delete {
+		current, err := owned(id)
+		if err != nil {
+			b.fail(BulkDelete, i, id, err)
+			continue
+		}
+		item := *current
+		item.IsDeleted = true
+		item.SetLastModifiedNow()
+		if b.write(BulkDelete, i, id, http.StatusNoContent, nil) {
+			records = append(records, &item)
+			versions = append(versions, &current.Version)
+		}
+	}
+
+	writeJson(w, r, http.StatusOK, b.written(s.items.PutMany(records, versions)))
+}
+
+// BulkServices creates, updates and deletes services of a producer, writing
+// them in transactions like BulkItems. Deleting a service leaves its items in place, like
+// DeleteService.
+func (s *V2HttpService) BulkServices(w http.ResponseWriter, r *http.Request) {
+	producer, err := s.producer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	var data BulkRequest
+	err = Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	existing, err := s.services.ReadMany(bulkIds(&data))
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	owned := func(serviceId string) (*Services.Service, error) {
+		service, ok := existing[serviceId]
+		if !ok || service.IsDeleted || !childOf(producer.SK, serviceId) {
+			return nil, notFound("service", serviceId)
+		}
+		return service, nil
+	}
+
+	b := &bulkWriter{r: r, ids: map[string]bool{}}
+	var records []*Services.Service
+	var versions []*int64
+
+	for i, raw := range data.Create {
+		var fields BulkServiceFields
+		var service Services.Service
+		err = decodeBulkEntry(raw, &fields)
+		if err == nil {
+			service.Name = fields.Name
+			err = service.New(Services.ServicePrefix, producer.SK)
+		}
+		if err != nil {
+			b.fail(BulkCreate, i, "", err)
+			continue
+		}
+		if b.write(BulkCreate, i, service.SK, http.StatusCreated, &service) {
+			records = append(records, &service)
+			versions = append(versions, nil)
+		}
+	}
+
+	for i, raw := range data.Update {
+		id := updateId(raw)
+		current, err := owned(id)
+		if id != "" && err != nil {
+			b.fail(BulkUpdate, i, id, err)
+			continue
+		}
+		entry := BulkServiceUpdate{Id: id}
+		if current != nil {
+			entry.Name = current.Name
+		}
+		err = decodeBulkEntry(raw, &entry)
+		if err != nil {
+			b.fail(BulkUpdate, i, id, err)
+			continue
+		}
+		service := *current
+		service.Name = entry.Name
+		service.SetLastModifiedNow()
+		if b.write(BulkUpdate, i, id, http.StatusOK, &service) {
+			records = append(records, &service)
+			versions = append(versions, &current.Version)
+		}
+	}
+
+	for i, id := range data.Delete {
+		current, err := owned(id)
+		if err != nil {
+			b.fail(BulkDelete, i, id, err)
+			continue
+		}
+		service := *current
+		service.IsDeleted = true
+		service.SetLastModifiedNow()
+		if b.write(BulkDelete, i, id, http.StatusNoContent, nil) {
+			records = append(records, &service)
+			versions = append(versions, &current.Version)
+		}
+	}
+
+	writeJson(w, r, http.StatusOK, b.written(s.services.PutMany(records, versions)))
+}
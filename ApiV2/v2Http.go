@@ -0,0 +1,841 @@
+package ApiV2
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Consumers"
+	"github.com/jonathanpatta/apartmentservices/Idempotency"
+	"github.com/jonathanpatta/apartmentservices/Items"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Orders"
+	"github.com/jonathanpatta/apartmentservices/Producers"
+	"github.com/jonathanpatta/apartmentservices/Services"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Subscriptions"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const PathPrefix = "/v2"
+
+// V2HttpService serves the resource oriented api. It is backed by the same
+// services as the legacy verb style routes.
+type V2HttpService struct {
+	producers     *Producers.ProducerService
+	services      *Services.ServiceService
+	items         *Items.ItemService
+	consumers     *Consumers.ConsumerService
+	orders        *Orders.OrderService
+	subscriptions *Subscriptions.SubscriptionService
+}
+
+func NewV2HttpService(settings *Settings.Settings) (*V2HttpService, error) {
+	producers, err := Producers.NewProducerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	services, err := Services.NewServiceService(settings)
+	if err != nil {
+		return nil, err
+	}
+	items, err := Items.NewItemService(settings)
+	if err != nil {
+		return nil, err
+	}
+	consumers, err := Consumers.NewConsumerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := Orders.NewOrderService(settings)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := Subscriptions.NewSubscriptionService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &V2HttpService{
+		producers:     producers,
+		services:      services,
+		items:         items,
+		consumers:     consumers,
+		orders:        orders,
+		subscriptions: subscriptions,
+	}, nil
+}
+
+// writeJson answers with data, tagged with its ETag when it has one. GETs
+// whose If-None-Match names that ETag get a 304 instead.
+func writeJson(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	etag := Utils.ETagOf(data)
+	if r.Method == http.MethodGet {
+		if Middleware.NotModified(w, r, etag) {
+			return
+		}
+	} else if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	Middleware.WriteJson(w, r, status, data)
+}
+
+// writeCreated answers a POST with 201 and the location of the new resource,
+// built from path segments that are escaped individually.
+func writeCreated(w http.ResponseWriter, r *http.Request, data interface{}, segments ...string) {
+	location := PathPrefix
+	for _, segment := range segments {
+		location += "/" + url.PathEscape(segment)
+	}
+	w.Header().Set("Location", location)
+	writeJson(w, r, http.StatusCreated, data)
+}
+
+// decodePatch applies the fields present in the request body on top of an
+// existing record. The record's keys and timestamps cannot be patched.
+func decodePatch(r *http.Request, target interface{}, meta *Utils.Meta) error {
+	original := *meta
+	err := Utils.DecodeAndValidate(r.Body, target)
+	*meta = original
+	return err
+}
+
+func childOf(parentId string, childId string) bool {
+	return strings.HasPrefix(childId, parentId+"_")
+}
+
+func notFound(kind string, id string) error {
+	return Utils.NewError(Utils.NotFound, "%v %v not found", kind, id)
+}
+
+func (s *V2HttpService) producer(r *http.Request) (*Producers.Producer, error) {
+	producerId := mux.Vars(r)["producerId"]
+	producer, err := s.producers.ReadListed(producerId)
+	if err != nil {
+		return nil, err
+	}
+	if producer.IsDeleted {
+		return nil, notFound("producer", producerId)
+	}
+	return producer, nil
+}
+
+func (s *V2HttpService) service(r *http.Request) (*Services.Service, error) {
+	vars := mux.Vars(r)
+	if !childOf(vars["producerId"], vars["serviceId"]) {
+		return nil, notFound("service", vars["serviceId"])
+	}
+	service, err := s.services.Read(vars["serviceId"])
+	if err != nil {
+		return nil, err
+	}
+	if service.IsDeleted {
+		return nil, notFound("service", vars["serviceId"])
+	}
+	return service, nil
+}
+
+func (s *V2HttpService) item(r *http.Request) (*Items.Item, error) {
+	vars := mux.Vars(r)
+	if !childOf(vars["producerId"], vars["serviceId"]) || !childOf(vars["serviceId"], vars["itemId"]) {
+		return nil, notFound("item", vars["itemId"])
+	}
+	item, err := s.items.Read(vars["itemId"])
+	if err != nil {
+		return nil, err
+	}
+	if item.IsDeleted || item.Hidden {
+		return nil, notFound("item", vars["itemId"])
+	}
+	return item, nil
+}
+
+func (s *V2HttpService) consumer(r *http.Request) (*Consumers.Consumer, error) {
+	consumerId := mux.Vars(r)["consumerId"]
+	consumer, err := s.consumers.Read(consumerId)
+	if err != nil {
+		return nil, err
+	}
+	if consumer.IsDeleted {
+		return nil, notFound("consumer", consumerId)
+	}
+	return consumer, nil
+}
+
+func (s *V2HttpService) order(r *http.Request) (*Orders.Order, error) {
+	vars := mux.Vars(r)
+	if !childOf(vars["consumerId"], vars["orderId"]) {
+		return nil, notFound("order", vars["orderId"])
+	}
+	order, err := s.orders.Read(vars["orderId"])
+	if err != nil {
+		return nil, err
+	}
+	if order.IsDeleted {
+		return nil, notFound("order", vars["orderId"])
+	}
+	return order, nil
+}
+
+func (s *V2HttpService) subscription(r *http.Request) (*Subscriptions.Subscription, error) {
+	vars := mux.Vars(r)
+	if !childOf(vars["consumerId"], vars["subscriptionId"]) {
+		return nil, notFound("subscription", vars["subscriptionId"])
+	}
+	subscription, err := s.subscriptions.Read(vars["subscriptionId"])
+	if err != nil {
+		return nil, err
+	}
+	if subscription.IsDeleted {
+		return nil, notFound("subscription", vars["subscriptionId"])
+	}
+	return subscription, nil
+}
+
+// Producers
+
+func (s *V2HttpService) ListProducers(w http.ResponseWriter, r *http.Request) {
+	producers, err := s.producers.List()
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, producers)
+}
+
+func (s *V2HttpService) CreateProducer(w http.ResponseWriter, r *http.Request) {
+	var data Producers.Producer
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	producer, err := s.producers.Create(&data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeCreated(w, r, producer, "producers", producer.SK)
+}
+
+func (s *V2HttpService) ReadProducer(w http.ResponseWriter, r *http.Request) {
+	producer, err := s.producer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, producer)
+}
+
+func (s *V2HttpService) PatchProducer(w http.ResponseWriter, r *http.Request) {
+	producer, err := s.producer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &producer.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	err = decodePatch(r, producer, &producer.Meta)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	producer, err = s.producers.Update(producer, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, producer)
+}
+
+func (s *V2HttpService) DeleteProducer(w http.ResponseWriter, r *http.Request) {
+	producer, err := s.producer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &producer.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	_, err = s.producers.Delete(producer.SK, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *V2HttpService) ListProducerItems(w http.ResponseWriter, r *http.Request) {
+	producer, err := s.producer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	items, err := s.producers.GetAllItems(producer.SK)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, items)
+}
+
+// Services
+
+func (s *V2HttpService) ListServices(w http.ResponseWriter, r *http.Request) {
+	producer, err := s.producer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	services, err := s.producers.GetServices(producer.SK)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, services)
+}
+
+func (s *V2HttpService) CreateService(w http.ResponseWriter, r *http.Request) {
+	producer, err := s.producer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	var data Services.Service
+	err = Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	service, err := s.services.Create(producer.SK, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeCreated(w, r, service, "producers", producer.SK, "services", service.SK)
+}
+
+func (s *V2HttpService) ReadService(w http.ResponseWriter, r *http.Request) {
+	service, err := s.service(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, service)
+}
+
+func (s *V2HttpService) PatchService(w http.ResponseWriter, r *http.Request) {
+	service, err := s.service(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &service.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	err = decodePatch(r, service, &service.Meta)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	service, err = s.services.Update(service, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, service)
+}
+
+func (s *V2HttpService) DeleteService(w http.ResponseWriter, r *http.Request) {
+	service, err := s.service(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &service.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	_, err = s.services.Delete(service.SK, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Items
+
+func (s *V2HttpService) ListItems(w http.ResponseWriter, r *http.Request) {
+	service, err := s.service(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	items, err := s.services.GetItems(service.SK)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, items)
+}
+
+func (s *V2HttpService) CreateItem(w http.ResponseWriter, r *http.Request) {
+	service, err := s.service(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	var data Items.Item
+	err = Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	item, err := s.items.Create(service.SK, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	producerId := mux.Vars(r)["producerId"]
+	writeCreated(w, r, item, "producers", producerId, "services", service.SK, "items", item.SK)
+}
+
+func (s *V2HttpService) ReadItem(w http.ResponseWriter, r *http.Request) {
+	item, err := s.item(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, item)
+}
+
+func (s *V2HttpService) PatchItem(w http.ResponseWriter, r *http.Request) {
+	item, err := s.item(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &item.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	err = decodePatch(r, item, &item.Meta)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	item, err = s.items.Update(item, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, item)
+}
+
+func (s *V2HttpService) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	item, err := s.item(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &item.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	_, err = s.items.Delete(item.SK, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Consumers
+
+func (s *V2HttpService) ListConsumers(w http.ResponseWriter, r *http.Request) {
+	consumers, err := s.consumers.List()
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, consumers)
+}
+
+func (s *V2HttpService) CreateConsumer(w http.ResponseWriter, r *http.Request) {
+	var data Consumers.Consumer
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	consumer, err := s.consumers.Create(&data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeCreated(w, r, consumer, "consumers", consumer.SK)
+}
+
+func (s *V2HttpService) ReadConsumer(w http.ResponseWriter, r *http.Request) {
+	consumer, err := s.consumer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, consumer)
+}
+
+func (s *V2HttpService) PatchConsumer(w http.ResponseWriter, r *http.Request) {
+	consumer, err := s.consumer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &consumer.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	err = decodePatch(r, consumer, &consumer.Meta)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	consumer, err = s.consumers.Update(consumer, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, consumer)
+}
+
+func (s *V2HttpService) DeleteConsumer(w http.ResponseWriter, r *http.Request) {
+	consumer, err := s.consumer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &consumer.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	_, err = s.consumers.Delete(consumer.SK, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Orders
+
+func (s *V2HttpService) ListOrders(w http.ResponseWriter, r *http.Request) {
+	consumer, err := s.consumer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	orders, err := s.orders.ListForConsumer(consumer.SK)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	data := []*Orders.Order{}
+	for _, order := range orders {
+		if !order.IsDeleted {
+			data = append(data, order)
+		}
+	}
+	writeJson(w, r, http.StatusOK, data)
+}
+
+func (s *V2HttpService) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	consumer, err := s.consumer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	var data Orders.Order
+	err = Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	user := Middleware.GetFirebaseUser(r.Context())
+	data.CreatedByName = user.Name
+	data.CreatedByUserId = user.UserId
+	data.CreatedByUserEmail = user.Email
+	data.CreatedByUserPicture = user.Picture
+
+	order, err := s.orders.Create(r.Context(), consumer.SK, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeCreated(w, r, order, "consumers", consumer.SK, "orders", order.SK)
+}
+
+func (s *V2HttpService) ReadOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := s.order(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, order)
+}
+
+func (s *V2HttpService) PatchOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := s.order(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &order.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	err = decodePatch(r, order, &order.Meta)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	order, err = s.orders.Update(r.Context(), order, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, order)
+}
+
+func (s *V2HttpService) DeleteOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := s.order(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &order.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	_, err = s.orders.Delete(r.Context(), order.SK, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Subscriptions
+
+func (s *V2HttpService) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	consumer, err := s.consumer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	subscriptions, err := s.subscriptions.ListForConsumer(consumer.SK)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	data := []*Subscriptions.Subscription{}
+	for _, subscription := range subscriptions {
+		if !subscription.IsDeleted {
+			data = append(data, subscription)
+		}
+	}
+	writeJson(w, r, http.StatusOK, data)
+}
+
+func (s *V2HttpService) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	consumer, err := s.consumer(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	var data Subscriptions.Subscription
+	err = Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	user := Middleware.GetFirebaseUser(r.Context())
+	data.CreatedByName = user.Name
+	data.CreatedByUserId = user.UserId
+	data.CreatedByUserEmail = user.Email
+	data.CreatedByUserPicture = user.Picture
+
+	subscription, err := s.subscriptions.Create(consumer.SK, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeCreated(w, r, subscription, "consumers", consumer.SK, "subscriptions", subscription.SK)
+}
+
+func (s *V2HttpService) ReadSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription, err := s.subscription(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, subscription)
+}
+
+func (s *V2HttpService) PatchSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription, err := s.subscription(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &subscription.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	err = decodePatch(r, subscription, &subscription.Meta)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	subscription, err = s.subscriptions.Update(subscription, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	writeJson(w, r, http.StatusOK, subscription)
+}
+
+func (s *V2HttpService) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription, err := s.subscription(r)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		return &subscription.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	_, err = s.subscriptions.Delete(subscription.SK, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
+	server, err := NewV2HttpService(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+	idempotency, err := Idempotency.NewIdempotencyService(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+	router := r.PathPrefix(PathPrefix).Subrouter()
+
+	router.Use(settings.MiddlewareService.ValidateToken)
+
+	producer := "/producers/{producerId}"
+	service := producer + "/services/{serviceId}"
+	consumer := "/consumers/{consumerId}"
+
+	router.HandleFunc("/producers", server.ListProducers).Methods("GET", "OPTIONS")
+	router.HandleFunc("/producers", server.CreateProducer).Methods("POST")
+	router.HandleFunc(producer, server.ReadProducer).Methods("GET", "OPTIONS")
+	router.HandleFunc(producer, server.PatchProducer).Methods("PATCH")
+	router.HandleFunc(producer, server.DeleteProducer).Methods("DELETE")
+	router.HandleFunc(producer+"/items", server.ListProducerItems).Methods("GET", "OPTIONS")
+	router.HandleFunc(producer+"/items/bulk", server.BulkItems).Methods("POST", "OPTIONS")
+
+	router.HandleFunc(producer+"/services", server.ListServices).Methods("GET", "OPTIONS")
+	router.HandleFunc(producer+"/services", server.CreateService).Methods("POST")
+	router.HandleFunc(producer+"/services/bulk", server.BulkServices).Methods("POST", "OPTIONS")
+	router.HandleFunc(service, server.ReadService).Methods("GET", "OPTIONS")
+	router.HandleFunc(service, server.PatchService).Methods("PATCH")
+	router.HandleFunc(service, server.DeleteService).Methods("DELETE")
+
+	router.HandleFunc(service+"/items", server.ListItems).Methods("GET", "OPTIONS")
+	router.HandleFunc(service+"/items", server.CreateItem).Methods("POST")
+	router.HandleFunc(service+"/items/{itemId}", server.ReadItem).Methods("GET", "OPTIONS")
+	router.HandleFunc(service+"/items/{itemId}", server.PatchItem).Methods("PATCH")
+	router.HandleFunc(service+"/items/{itemId}", server.DeleteItem).Methods("DELETE")
+
+	router.HandleFunc("/consumers", server.ListConsumers).Methods("GET", "OPTIONS")
+	router.HandleFunc("/consumers", server.CreateConsumer).Methods("POST")
+	router.HandleFunc(consumer, server.ReadConsumer).Methods("GET", "OPTIONS")
+	router.HandleFunc(consumer, server.PatchConsumer).Methods("PATCH")
+	router.HandleFunc(consumer, server.DeleteConsumer).Methods("DELETE")
+
+	router.HandleFunc(consumer+"/orders", server.ListOrders).Methods("GET", "OPTIONS")
+	router.HandleFunc(consumer+"/orders", idempotency.Handler(server.CreateOrder)).Methods("POST")
+	router.HandleFunc(consumer+"/orders/{orderId}", server.ReadOrder).Methods("GET", "OPTIONS")
+	router.HandleFunc(consumer+"/orders/{orderId}", server.PatchOrder).Methods("PATCH")
+	router.HandleFunc(consumer+"/orders/{orderId}", server.DeleteOrder).Methods("DELETE")
+
+	router.HandleFunc(consumer+"/subscriptions", server.ListSubscriptions).Methods("GET", "OPTIONS")
+	router.HandleFunc(consumer+"/subscriptions", idempotency.Handler(server.CreateSubscription)).Methods("POST")
+	router.HandleFunc(consumer+"/subscriptions/{subscriptionId}", server.ReadSubscription).Methods("GET", "OPTIONS")
+	router.HandleFunc(consumer+"/subscriptions/{subscriptionId}", server.PatchSubscription).Methods("PATCH")
+	router.HandleFunc(consumer+"/subscriptions/{subscriptionId}", server.DeleteSubscription).Methods("DELETE")
+}
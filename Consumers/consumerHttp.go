@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
 	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
 	"log"
 	"net/http"
 )
@@ -26,53 +28,53 @@ func NewConsumerHttpService(settings *Settings.Settings) (*ConsumerHttpService,
 
 func (s *ConsumerHttpService) Create(w http.ResponseWriter, r *http.Request) {
 	var data Consumer
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	consumer, err := s.service.Create(&data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(consumer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ConsumerHttpService) CreateOrGet(w http.ResponseWriter, r *http.Request) {
 	var data Consumer
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	consumer, err := s.service.CreateOrGet(&data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(consumer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -85,19 +87,23 @@ func (s *ConsumerHttpService) Read(w http.ResponseWriter, r *http.Request) {
 
 	consumer, err := s.service.Read(data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	if Middleware.NotModified(w, r, Utils.ETagOf(consumer)) {
 		return
 	}
 
 	outData, err := json.Marshal(consumer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -110,72 +116,89 @@ func (s *ConsumerHttpService) ReadFromUserId(w http.ResponseWriter, r *http.Requ
 
 	consumer, err := s.service.ReadFromUserId(data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	if Middleware.NotModified(w, r, Utils.ETagOf(consumer)) {
 		return
 	}
 
 	outData, err := json.Marshal(consumer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ConsumerHttpService) Update(w http.ResponseWriter, r *http.Request) {
 
 	var data Consumer
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	consumer, err := s.service.Update(&data)
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		current, err := s.service.Read(data.SK)
+		if err != nil {
+			return nil, err
+		}
+		return &current.Meta, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
+	consumer, err := s.service.Update(&data, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", Utils.ETagOf(consumer))
+
 	outData, err := json.Marshal(consumer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ConsumerHttpService) Delete(w http.ResponseWriter, r *http.Request) {
 	var data string
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	consumer, err := s.service.Delete(data)
+	consumer, err := s.service.Delete(data, nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(consumer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -183,20 +206,15 @@ func (s *ConsumerHttpService) List(w http.ResponseWriter, r *http.Request) {
 
 	consumer, err := s.service.List()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	outData, err := json.Marshal(consumer)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if Middleware.NotModified(w, r, Utils.ETagOf(consumer)) {
 		return
 	}
 
-	_, err = fmt.Fprint(w, string(outData))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	Middleware.WriteJson(w, r, http.StatusOK, consumer)
 }
 
 func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
@@ -2,8 +2,6 @@ package Consumers
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
@@ -16,8 +14,9 @@ const ConsumerPrefix = "CONSUMER#"
 
 type Consumer struct {
 	Utils.Meta
-	Id     string
-	UserId string `json:"user_id,omitempty"`
+	Id        string
+	UserId    string `json:"user_id,omitempty" validate:"max=128"`
+	Suspended bool   `json:"suspended,omitempty"`
 }
 
 type ConsumerService struct {
@@ -119,7 +118,7 @@ func (s *ConsumerService) ReadFromUserId(userId string) (*Consumer, error) {
 		return nil, err
 	}
 	if len(data) != 1 {
-		return nil, errors.New(fmt.Sprintf("length of return items not 1 got %v", len(data)))
+		return nil, Utils.NotFoundOrAmbiguous("consumer for user", userId, len(data))
 	}
 
 	return &data[0], nil
@@ -152,13 +151,50 @@ func (s *ConsumerService) Read(consumerId string) (*Consumer, error) {
 		return nil, err
 	}
 	if len(data) != 1 {
-		return nil, errors.New(fmt.Sprintf("length of return items not 1 got %v", len(data)))
+		return nil, Utils.NotFoundOrAmbiguous("consumer", consumerId, len(data))
 	}
 
 	return &data[0], nil
 }
 
-func (s *ConsumerService) Update(in *Consumer) (*Consumer, error) {
+// FindFromUserId looks up the consumer linked to a firebase user.
+//
+// Returns nil without an error when the user has no consumer.
+func (s *ConsumerService) FindFromUserId(userId string) (*Consumer, error) {
+	keyFilter := expression.Key("PK").Equal(expression.Value(ConsumerPrefix))
+
+	filterExpression := expression.Name("UserId").Equal(expression.Value(userId))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filterExpression).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.db.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+		ConsistentRead:            aws.Bool(false),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data []Consumer
+	err = attributevalue.UnmarshalListOfMaps(out.Items, &data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	return &data[0], nil
+}
+
+func (s *ConsumerService) Update(in *Consumer, ifVersion *int64) (*Consumer, error) {
 
 	consumer, err := s.Read(in.SK)
 	if err != nil {
@@ -173,6 +209,30 @@ func (s *ConsumerService) Update(in *Consumer) (*Consumer, error) {
 		return nil, err
 	}
 
+	err = s.dynamodbSettings.PutVersioned(item, ifVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	err = attributevalue.UnmarshalMap(item, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return in, nil
+}
+
+// Erase unlinks the consumer from its firebase user and tombstones it.
+func (s *ConsumerService) Erase(in *Consumer) (*Consumer, error) {
+	in.UserId = ""
+	in.IsDeleted = true
+	in.SetLastModifiedNow()
+
+	item, err := attributevalue.MarshalMap(in)
+	if err != nil {
+		return nil, err
+	}
+
 	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
 		Item:      item,
 		TableName: s.dynamodbSettings.TableName,
@@ -181,19 +241,42 @@ func (s *ConsumerService) Update(in *Consumer) (*Consumer, error) {
 		return nil, err
 	}
 
-	err = attributevalue.UnmarshalMap(item, in)
+	return in, nil
+}
+
+// SetSuspended marks the consumer as suspended or reinstates it.
+func (s *ConsumerService) SetSuspended(consumerId string, suspended bool) (*Consumer, error) {
+	consumer, err := s.Read(consumerId)
 	if err != nil {
 		return nil, err
 	}
 
-	return in, nil
+	consumer.Suspended = suspended
+	consumer.SetLastModifiedNow()
+
+	item, err := attributevalue.MarshalMap(consumer)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return consumer, nil
 }
 
 func (s *ConsumerService) List() ([]*Consumer, error) {
 
 	keyFilter := expression.Key("PK").Equal(expression.Value(ConsumerPrefix))
 
-	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).Build()
+	filter := expression.Name("IsDeleted").NotEqual(expression.Value(true))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
 	if err != nil {
 		return nil, err
 	}
@@ -218,6 +301,24 @@ func (s *ConsumerService) List() ([]*Consumer, error) {
 	return data, nil
 }
 
-func (s *ConsumerService) Delete(consumerId string) (*Consumer, error) {
-	return nil, nil
+func (s *ConsumerService) Delete(consumerId string, ifVersion *int64) (*Consumer, error) {
+	consumer, err := s.Read(consumerId)
+	if err != nil {
+		return nil, err
+	}
+
+	consumer.IsDeleted = true
+	consumer.SetLastModifiedNow()
+
+	data, err := attributevalue.MarshalMap(consumer)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.dynamodbSettings.PutVersioned(data, ifVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return consumer, nil
 }
@@ -0,0 +1,118 @@
+package Events
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"sync"
+	"time"
+)
+
+// Event is one change published on a topic.
+type Event struct {
+	Id   string          `json:"id"`
+	Type string          `json:"type"`
+	Time int64           `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Broker carries events between publishers and subscribers. MemoryBroker
+// only reaches subscribers in the same process; deployments running more
+// than one instance plug in a broker backed by a shared queue instead.
+type Broker interface {
+	Publish(ctx context.Context, topic string, event *Event) error
+	// Subscribe delivers the events published on topic until ctx is done,
+	// then closes the channel.
+	Subscribe(ctx context.Context, topic string) (<-chan *Event, error)
+}
+
+// Bus publishes typed events through a Broker.
+type Bus struct {
+	broker Broker
+}
+
+func NewBus(broker Broker) *Bus {
+	return &Bus{broker: broker}
+}
+
+// Publish sends data on each topic as an event of the given type. Failures
+// are logged rather than returned, since the change the event reports has
+// already been made.
+func (b *Bus) Publish(ctx context.Context, eventType string, data interface{}, topics ...string) {
+	logger := Logger.FromContext(ctx)
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		logger.Error("could not encode event", "type", eventType, "error", err)
+		return
+	}
+	event := &Event{
+		Id:   uuid.New().String(),
+		Type: eventType,
+		Time: time.Now().Unix(),
+		Data: raw,
+	}
+	for _, topic := range topics {
+		err = b.broker.Publish(ctx, topic, event)
+		if err != nil {
+			logger.Error("could not publish event", "type", eventType, "topic", topic, "error", err)
+		}
+	}
+}
+
+func (b *Bus) Subscribe(ctx context.Context, topic string) (<-chan *Event, error) {
+	return b.broker.Subscribe(ctx, topic)
+}
+
+// subscriberBuffer is how many events a subscriber can fall behind by
+// before MemoryBroker drops events for it.
+const subscriberBuffer = 32
+
+type MemoryBroker struct {
+	mu     sync.Mutex
+	topics map[string]map[chan *Event]bool
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{topics: map[string]map[chan *Event]bool{}}
+}
+
+// Publish never blocks on a slow subscriber, it drops the event for that
+// subscriber instead.
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, event *Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.topics[topic] {
+		select {
+		case ch <- event:
+		default:
+			Logger.FromContext(ctx).Warn("dropped event for slow subscriber", "type", event.Type, "topic", topic)
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, topic string) (<-chan *Event, error) {
+	ch := make(chan *Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.topics[topic] == nil {
+		b.topics[topic] = map[chan *Event]bool{}
+	}
+	b.topics[topic][ch] = true
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.topics[topic], ch)
+		if len(b.topics[topic]) == 0 {
+			delete(b.topics, topic)
+		}
+		b.mu.Unlock()
+		close(ch)
+	}()
+	return ch, nil
+}
@@ -0,0 +1,85 @@
+package Events
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"net/http"
+	"time"
+)
+
+// heartbeatInterval keeps idle streams from being closed by proxies.
+const heartbeatInterval = 15 * time.Second
+
+// Stream serves the events of topic to the client as server-sent events
+// until the client goes away. When initial is not nil it is sent first, as an
+// event of type initialType, so the client starts from the current state
+// rather than waiting for the next change.
+//
+// Streams need a response writer that can flush, which the Lambda adapter
+// does not provide, and end at the server's write timeout when one is set;
+// EventSource clients reconnect on their own. Events are not stored, so the Last-Event-ID
+// a client reconnects with is ignored: what was published while it was away
+// is lost, and it should rely on the initial event or read the current state
+// again instead.
+func (b *Bus) Stream(w http.ResponseWriter, r *http.Request, topic string, initialType string, initial interface{}) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		Middleware.WriteError(w, r, Utils.NewError(Utils.BadRequest, "event streams are not supported by this deployment"))
+		return
+	}
+
+	events, err := b.Subscribe(r.Context(), topic)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	logger := Logger.FromContext(r.Context())
+	if initial != nil {
+		raw, err := json.Marshal(initial)
+		if err != nil {
+			logger.Error("could not encode event", "type", initialType, "error", err)
+			return
+		}
+		writeEvent(w, &Event{Type: initialType, Time: time.Now().Unix(), Data: raw})
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			err = writeEvent(w, event)
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event *Event) error {
+	if event.Id != "" {
+		_, err := fmt.Fprintf(w, "id: %v\n", event.Id)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "event: %v\ndata: %s\n\n", event.Type, event.Data)
+	return err
+}
@@ -6,35 +6,38 @@ import (
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/jonathanpatta/apartmentservices/Metrics"
 	"github.com/jonathanpatta/apartmentservices/Settings"
+	"io/ioutil"
 	"strings"
 )
 
+const ImagesPrefix = "static/images/"
+
 type Images struct {
-	Data     string `json:"data,omitempty"`
-	FileName string `json:"file_name,omitempty"`
-	FileType string `json:"file_type,omitempty"`
+	Data     string `json:"data,omitempty" validate:"required"`
+	FileName string `json:"file_name,omitempty" validate:"required,max=200"`
+	FileType string `json:"file_type,omitempty" validate:"max=100"`
 	Bytes    []byte `json:"bytes,omitempty"`
 }
 
 type S3FileService struct {
 	cli        *s3.Client
 	s3Settings *Settings.S3Settings
-	region     string
 }
 
 func NewS3FileService(settings *Settings.Settings) (*S3FileService, error) {
 	return &S3FileService{
 		cli:        settings.S3Settings.Cli,
 		s3Settings: settings.S3Settings,
-		region:     settings.Region,
 	}, nil
 }
 
 func (s *S3FileService) UploadImages(imgs []Images) ([]string, error) {
 	var urls []string
 	for _, img := range imgs {
-		key := "static/images/" + img.FileName
+		key := ImagesPrefix + img.FileName
 		imgBytes, err := ProcessImage(img.Bytes)
 		if err != nil {
 			return nil, err
@@ -48,13 +51,86 @@ func (s *S3FileService) UploadImages(imgs []Images) ([]string, error) {
 		if err != nil {
 			return urls, err
 		}
+		Metrics.ImagesUploaded.Inc()
 		key = strings.Replace(key, " ", "+", -1)
-		newUrl := fmt.Sprintf("https://%v.s3-%v.amazonaws.com/%v", s.s3Settings.BucketName, s.region, key)
-		urls = append(urls, newUrl)
+		urls = append(urls, s.s3Settings.ObjectUrl(key))
 	}
 	return urls, nil
 }
 
-func (s *S3FileService) DeleteImages() ([]string, error) {
-	return nil, nil
+// UserImagesPrefix is the key prefix of every image uploaded by a user.
+func UserImagesPrefix(userId string) string {
+	return ImagesPrefix + userId + "_"
+}
+
+// IsUserImageUrl reports whether url points at an image in the bucket that
+// was uploaded by the user.
+func (s *S3FileService) IsUserImageUrl(userId string, url string) bool {
+	prefix := strings.Replace(UserImagesPrefix(userId), " ", "+", -1)
+	return strings.HasPrefix(url, s.s3Settings.ObjectUrl(prefix))
+}
+
+// ListUserImages returns the keys of every image uploaded by the user.
+func (s *S3FileService) ListUserImages(userId string) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(s.cli, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.s3Settings.BucketName),
+		Prefix: aws.String(UserImagesPrefix(userId)),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+	}
+	return keys, nil
+}
+
+func (s *S3FileService) ReadImage(key string) ([]byte, error) {
+	result, err := s.cli.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.s3Settings.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer result.Body.Close()
+
+	return ioutil.ReadAll(result.Body)
+}
+
+// DeleteImages removes the given keys from the bucket and returns the keys
+// that were deleted.
+func (s *S3FileService) DeleteImages(keys []string) ([]string, error) {
+	var deleted []string
+	// DeleteObjects accepts at most 1000 keys per call.
+	for start := 0; start < len(keys); start += 1000 {
+		end := start + 1000
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		var objects []types.ObjectIdentifier
+		for _, key := range keys[start:end] {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		out, err := s.cli.DeleteObjects(context.Background(), &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.s3Settings.BucketName),
+			Delete: &types.Delete{Objects: objects},
+		})
+		if err != nil {
+			return deleted, err
+		}
+		for _, object := range out.Deleted {
+			deleted = append(deleted, aws.ToString(object.Key))
+		}
+		if len(out.Errors) > 0 {
+			return deleted, fmt.Errorf("unable to delete %v: %v", aws.ToString(out.Errors[0].Key), aws.ToString(out.Errors[0].Message))
+		}
+	}
+	return deleted, nil
 }
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Logger"
 	"github.com/jonathanpatta/apartmentservices/Middleware"
 	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
 	"log"
 	"net/http"
 	"strings"
@@ -34,9 +36,9 @@ func (s *S3FileHttpService) UploadImagesBase64(w http.ResponseWriter, r *http.Re
 
 	var imgData []Images
 
-	err := json.NewDecoder(r.Body).Decode(&imgData)
+	err := Utils.DecodeAndValidate(r.Body, &imgData)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
@@ -44,7 +46,7 @@ func (s *S3FileHttpService) UploadImagesBase64(w http.ResponseWriter, r *http.Re
 		data := make([]byte, base64.StdEncoding.DecodedLen(len(img.Data)))
 		_, err := base64.StdEncoding.Decode(data, []byte(img.Data))
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			Middleware.WriteError(w, r, Utils.WrapError(Utils.BadRequest, err))
 			return
 		}
 		imgData[i].Bytes = data
@@ -55,19 +57,19 @@ func (s *S3FileHttpService) UploadImagesBase64(w http.ResponseWriter, r *http.Re
 
 	urls, err := s.service.UploadImages(imgData)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(urls)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -78,7 +80,7 @@ func (s *S3FileHttpService) UploadImages(w http.ResponseWriter, r *http.Request)
 	// 32 MB is the default used by FormFile() function
 	BulkFileSize := int64(4 * 1024 * 1024)
 	if err := r.ParseMultipartForm(BulkFileSize); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, Utils.WrapError(Utils.BadRequest, err))
 		return
 	}
 
@@ -86,8 +88,7 @@ func (s *S3FileHttpService) UploadImages(w http.ResponseWriter, r *http.Request)
 	// They are accessible only after ParseMultipartForm is called
 	files := r.MultipartForm.File["file"]
 
-	var errNew string
-	var httpStatus int
+	var errNew error
 
 	var images []Images
 
@@ -95,8 +96,7 @@ func (s *S3FileHttpService) UploadImages(w http.ResponseWriter, r *http.Request)
 		// Open the file
 		file, err := fileHeader.Open()
 		if err != nil {
-			errNew = err.Error()
-			httpStatus = http.StatusInternalServerError
+			errNew = err
 			break
 		}
 
@@ -105,22 +105,18 @@ func (s *S3FileHttpService) UploadImages(w http.ResponseWriter, r *http.Request)
 		buff := make([]byte, 10*1024*1024)
 		n, err := file.Read(buff)
 		if err != nil {
-			errNew = err.Error()
-			httpStatus = http.StatusInternalServerError
+			errNew = err
 			break
 		}
 
 		buff = buff[:n]
 
-		fmt.Println(n, len(buff))
-
 		// checking the content type
 		// so we don't allow files other than images
 		filetype := http.DetectContentType(buff)
-		fmt.Println(!strings.Contains(filetype, "image/"), filetype)
+		Logger.FromContext(r.Context()).Info("image upload", "file_name", fileHeader.Filename, "file_type", filetype, "bytes", n)
 		if !strings.Contains(filetype, "image/") && filetype != "application/octet-stream" {
-			errNew = "The provided file format is not allowed. Please upload a JPEG,JPG or PNG image"
-			httpStatus = http.StatusBadRequest
+			errNew = Utils.NewError(Utils.Validation, "The provided file format is not allowed. Please upload a JPEG,JPG or PNG image")
 			break
 		}
 
@@ -132,35 +128,26 @@ func (s *S3FileHttpService) UploadImages(w http.ResponseWriter, r *http.Request)
 		})
 	}
 
-	if errNew != "" {
-		message := errNew
-		messageType := "E"
-
-		resp := map[string]interface{}{
-			"messageType": messageType,
-			"message":     message,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(httpStatus)
-		json.NewEncoder(w).Encode(resp)
+	if errNew != nil {
+		Middleware.WriteError(w, r, errNew)
 		return
 	}
 
 	urls, err := s.service.UploadImages(images)
 	if err != nil {
-		errNew = err.Error()
-		httpStatus = http.StatusInternalServerError
+		Middleware.WriteError(w, r, err)
+		return
 	}
 
 	outData, err := json.Marshal(urls)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 
 }
@@ -0,0 +1,352 @@
+package Flags
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"hash/fnv"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const FlagPrefix = "FLAG#"
+
+// Flows being rolled out behind a flag.
+const (
+	Subscriptions = "subscriptions"
+	Delivery      = "delivery"
+	Payments      = "payments"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Flag turns a feature on for part of the users. A flag is on for a user
+// when it is Enabled and either lists the user in UserIds, or the user
+// holds one of the Roles, lives in one of the Communities, and falls within
+// the Percentage. Empty Roles or Communities do not restrict the flag.
+//
+// Users are assigned to the Percentage by a hash of the flag name and their
+// id, so raising it only adds users, and each flag picks different ones.
+type Flag struct {
+	Utils.Meta
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Enabled     bool     `json:"enabled"`
+	UserIds     []string `json:"user_ids,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Communities []string `json:"communities,omitempty"`
+	Percentage  int      `json:"percentage"`
+}
+
+// FlagInput is what an admin sets on a flag.
+type FlagInput struct {
+	Description string   `json:"description,omitempty" validate:"max=500"`
+	Enabled     bool     `json:"enabled"`
+	UserIds     []string `json:"user_ids,omitempty" validate:"max=1000"`
+	Roles       []string `json:"roles,omitempty" validate:"max=20"`
+	Communities []string `json:"communities,omitempty" validate:"max=200"`
+	Percentage  int      `json:"percentage" validate:"min=0,max=100"`
+}
+
+// IsOn reports whether the flag is on for the user.
+func (f *Flag) IsOn(user *Middleware.FirebaseUser) bool {
+	if !f.Enabled || f.IsDeleted {
+		return false
+	}
+	if user.UserId != "" && contains(f.UserIds, user.UserId) {
+		return true
+	}
+	if len(f.Roles) > 0 {
+		found := false
+		for _, role := range f.Roles {
+			found = found || user.HasRole(role)
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Communities) > 0 && !contains(f.Communities, user.Community) {
+		return false
+	}
+	if f.Percentage >= 100 {
+		return true
+	}
+	if user.UserId == "" {
+		return false
+	}
+	return bucket(f.Name, user.UserId) < f.Percentage
+}
+
+// bucket places a user in one of 100 buckets for the flag.
+func bucket(name string, userId string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + "/" + userId))
+	return int(h.Sum32() % 100)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FlagService stores flags in the table and evaluates them. Evaluation uses
+// every flag loaded at once and kept for cacheTTL, so gating a route does
+// not cost a read; changes made on other instances show up once it expires.
+type FlagService struct {
+	db        *dynamodb.Client
+	tableName *string
+	cacheTTL  time.Duration
+
+	mu     sync.Mutex
+	flags  map[string]*Flag
+	loaded time.Time
+}
+
+func NewFlagService(db *dynamodb.Client, tableName *string, cacheTTL time.Duration) *FlagService {
+	return &FlagService{
+		db:        db,
+		tableName: tableName,
+		cacheTTL:  cacheTTL,
+	}
+}
+
+// List returns every flag, ordered by name.
+func (s *FlagService) List() ([]*Flag, error) {
+	keyFilter := expression.Key("PK").Equal(expression.Value(FlagPrefix))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(s.db, &dynamodb.QueryInput{
+		TableName:                 s.tableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+	})
+
+	flags := []*Flag{}
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		var page []*Flag
+		err = attributevalue.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, page...)
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags, nil
+}
+
+func (s *FlagService) Read(name string) (*Flag, error) {
+	key, err := attributevalue.MarshalMap(map[string]string{
+		"PK": FlagPrefix,
+		"SK": FlagPrefix + name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.db.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: s.tableName,
+		Key:       key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, Utils.NewError(Utils.NotFound, "flag %v not found", name)
+	}
+
+	var flag Flag
+	err = attributevalue.UnmarshalMap(out.Item, &flag)
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// Put creates the named flag or replaces its settings.
+func (s *FlagService) Put(name string, data *FlagInput) (*Flag, error) {
+	if !namePattern.MatchString(name) || len(name) > 64 {
+		return nil, Utils.NewError(Utils.BadRequest, "flag names are up to 64 lower case letters, digits, '.', '_' and '-'")
+	}
+
+	flag := &Flag{}
+	existing, err := s.Read(name)
+	if err == nil {
+		flag.Meta = existing.Meta
+	} else if Utils.ErrorCodeOf(err) == Utils.NotFound {
+		flag.PK = FlagPrefix
+		flag.SK = FlagPrefix + name
+		flag.SetCreatedAtNow()
+	} else {
+		return nil, err
+	}
+
+	flag.Name = name
+	flag.Description = data.Description
+	flag.Enabled = data.Enabled
+	flag.UserIds = data.UserIds
+	flag.Roles = data.Roles
+	flag.Communities = data.Communities
+	flag.Percentage = data.Percentage
+	flag.SetLastModifiedNow()
+
+	err = s.write(flag)
+	if err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+// SetEnabled switches a flag on or off without touching who it targets.
+func (s *FlagService) SetEnabled(name string, enabled bool) (*Flag, error) {
+	flag, err := s.Read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	flag.Enabled = enabled
+	flag.SetLastModifiedNow()
+
+	err = s.write(flag)
+	if err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+func (s *FlagService) Delete(name string) (*Flag, error) {
+	flag, err := s.Read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := attributevalue.MarshalMap(map[string]string{
+		"PK": flag.PK,
+		"SK": flag.SK,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: s.tableName,
+		Key:       key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return flag, nil
+}
+
+func (s *FlagService) write(flag *Flag) error {
+	item, err := attributevalue.MarshalMap(flag)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: s.tableName,
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *FlagService) invalidate() {
+	s.mu.Lock()
+	s.loaded = time.Time{}
+	s.mu.Unlock()
+}
+
+// cached returns the flags by name, reloading them once they are older than
+// the cache ttl. When reloading fails the previous flags are kept until the
+// next attempt, one ttl later.
+func (s *FlagService) cached(ctx context.Context) map[string]*Flag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.flags != nil && time.Since(s.loaded) < s.cacheTTL {
+		return s.flags
+	}
+	s.loaded = time.Now()
+
+	flags, err := s.List()
+	if err != nil {
+		Logger.FromContext(ctx).Error("could not load feature flags", "error", err)
+		if s.flags == nil {
+			s.flags = map[string]*Flag{}
+		}
+		return s.flags
+	}
+
+	s.flags = map[string]*Flag{}
+	for _, flag := range flags {
+		s.flags[flag.Name] = flag
+	}
+	return s.flags
+}
+
+// Enabled reports whether the named flag is on for the user. Flags that do
+// not exist are off.
+func (s *FlagService) Enabled(ctx context.Context, user *Middleware.FirebaseUser, name string) bool {
+	flag, found := s.cached(ctx)[name]
+	return found && flag.IsOn(user)
+}
+
+// EnabledFor reports whether the named flag is on for the user making the
+// request, for handlers that change behaviour behind a flag.
+func (s *FlagService) EnabledFor(r *http.Request, name string) bool {
+	return s.Enabled(r.Context(), Middleware.GetFirebaseUser(r.Context()), name)
+}
+
+// Evaluate returns the names of the flags on for the user.
+func (s *FlagService) Evaluate(ctx context.Context, user *Middleware.FirebaseUser) []string {
+	names := []string{}
+	for name, flag := range s.cached(ctx) {
+		if flag.IsOn(user) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Require gates routes behind the named flag, answering 404 to users it is
+// off for. It must run after ValidateToken.
+func (s *FlagService) Require(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.EnabledFor(r, name) {
+				Middleware.WriteError(w, r, Utils.NewError(Utils.NotFound, "%v is not available", name))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
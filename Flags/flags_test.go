@@ -0,0 +1,102 @@
+package Flags
+
+import (
+	"fmt"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"testing"
+)
+
+func TestIsOn(t *testing.T) {
+	admin := &Middleware.FirebaseUser{UserId: "u1", Roles: []string{"admin"}, Community: "north"}
+	resident := &Middleware.FirebaseUser{UserId: "u2", Community: "south"}
+	anonymous := &Middleware.FirebaseUser{}
+
+	tests := []struct {
+		name string
+		flag Flag
+		user *Middleware.FirebaseUser
+		want bool
+	}{
+		{"disabled", Flag{Percentage: 100}, admin, false},
+		{"disabled listed user", Flag{UserIds: []string{"u1"}, Percentage: 100}, admin, false},
+		{"deleted", Flag{Enabled: true, Percentage: 100, Meta: Utils.Meta{IsDeleted: true}}, admin, false},
+		{"100%", Flag{Enabled: true, Percentage: 100}, resident, true},
+		{"100% anonymous", Flag{Enabled: true, Percentage: 100}, anonymous, true},
+		{"0%", Flag{Enabled: true}, resident, false},
+		{"0% anonymous", Flag{Enabled: true}, anonymous, false},
+		{"listed user at 0%", Flag{Enabled: true, UserIds: []string{"u2"}}, resident, true},
+		{"listed user outside roles", Flag{Enabled: true, UserIds: []string{"u2"}, Roles: []string{"admin"}}, resident, true},
+		{"listed user outside communities", Flag{Enabled: true, UserIds: []string{"u2"}, Communities: []string{"north"}}, resident, true},
+		{"other user listed", Flag{Enabled: true, UserIds: []string{"u1"}}, resident, false},
+		{"role held", Flag{Enabled: true, Roles: []string{"staff", "admin"}, Percentage: 100}, admin, true},
+		{"role missing", Flag{Enabled: true, Roles: []string{"admin"}, Percentage: 100}, resident, false},
+		{"role held at 0%", Flag{Enabled: true, Roles: []string{"admin"}}, admin, false},
+		{"community matches", Flag{Enabled: true, Communities: []string{"south"}, Percentage: 100}, resident, true},
+		{"community differs", Flag{Enabled: true, Communities: []string{"north"}, Percentage: 100}, resident, false},
+		{"role held in other community", Flag{Enabled: true, Roles: []string{"admin"}, Communities: []string{"south"}, Percentage: 100}, admin, false},
+		{"no community", Flag{Enabled: true, Communities: []string{"south"}, Percentage: 100}, anonymous, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.flag.IsOn(test.user); got != test.want {
+				t.Errorf("IsOn = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsOnFollowsBucket(t *testing.T) {
+	for _, percentage := range []int{1, 25, 50, 99} {
+		flag := Flag{Name: "checkout", Enabled: true, Percentage: percentage}
+		on := 0
+		for i := 0; i < 1000; i++ {
+			userId := fmt.Sprint("user-", i)
+			want := bucket(flag.Name, userId) < percentage
+			if got := flag.IsOn(&Middleware.FirebaseUser{UserId: userId}); got != want {
+				t.Fatalf("%v%%: IsOn(%v) = %v, want %v", percentage, userId, got, want)
+			}
+			if want {
+				on++
+			}
+		}
+		// fnv spreads the users evenly enough to land within 5% of the target.
+		if on < percentage*10-50 || on > percentage*10+50 {
+			t.Errorf("%v%% turned the flag on for %v of 1000 users", percentage, on)
+		}
+	}
+}
+
+func TestRaisingPercentageOnlyAddsUsers(t *testing.T) {
+	was := map[string]bool{}
+	for percentage := 0; percentage <= 100; percentage += 10 {
+		flag := Flag{Name: "delivery", Enabled: true, Percentage: percentage}
+		for i := 0; i < 500; i++ {
+			userId := fmt.Sprint("user-", i)
+			on := flag.IsOn(&Middleware.FirebaseUser{UserId: userId})
+			if was[userId] && !on {
+				t.Fatalf("raising to %v%% turned the flag off for %v", percentage, userId)
+			}
+			was[userId] = on
+		}
+	}
+}
+
+func TestBucket(t *testing.T) {
+	if bucket("a", "user") != bucket("a", "user") {
+		t.Error("bucket is not stable")
+	}
+	differs := false
+	for i := 0; i < 100 && !differs; i++ {
+		userId := fmt.Sprint("user-", i)
+		differs = bucket("a", userId) != bucket("b", userId)
+	}
+	if !differs {
+		t.Error("bucket places users the same for every flag")
+	}
+	for i := 0; i < 1000; i++ {
+		if b := bucket("a", fmt.Sprint("user-", i)); b < 0 || b >= 100 {
+			t.Fatalf("bucket = %v, want 0 to 99", b)
+		}
+	}
+}
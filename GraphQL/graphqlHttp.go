@@ -0,0 +1,78 @@
+package GraphQL
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"log"
+	"net/http"
+)
+
+type Request struct {
+	Query         string                 `json:"query" validate:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions"`
+}
+
+type GraphQLHttpService struct {
+	resolver *Resolver
+	schema   *graphql.Schema
+}
+
+func NewGraphQLHttpService(settings *Settings.Settings) (*GraphQLHttpService, error) {
+	resolver, err := NewResolver(settings)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := graphql.ParseSchema(Schema, resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GraphQLHttpService{
+		resolver: resolver,
+		schema:   schema,
+	}, nil
+}
+
+// Query runs a query and answers with its data and errors. Like other
+// GraphQL servers it answers 200 even when resolvers fail.
+func (s *GraphQLHttpService) Query(w http.ResponseWriter, r *http.Request) {
+	var data Request
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	ctx := s.resolver.withLoaders(r.Context())
+	resp := s.schema.Exec(ctx, data.Query, data.OperationName, data.Variables)
+
+	outData, err := json.Marshal(resp)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json;charset=UTF-8")
+	_, err = w.Write(outData)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+	}
+}
+
+func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
+	server, err := NewGraphQLHttpService(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+	router := r.PathPrefix("/graphql").Subrouter()
+
+	router.Use(settings.MiddlewareService.ValidateToken)
+
+	router.HandleFunc("", server.Query).Methods("POST", "OPTIONS")
+}
@@ -0,0 +1,107 @@
+package GraphQL
+
+import (
+	"sync"
+	"time"
+)
+
+// batchWait is how long a loader collects keys before fetching them, long
+// enough for sibling resolvers that run concurrently to join the batch.
+const batchWait = time.Millisecond
+
+// batchFunc fetches the values of several keys at once.
+type batchFunc func(keys []string) (map[string]interface{}, error)
+
+type loadResult struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// loader batches and caches lookups for the duration of one request.
+type loader struct {
+	batch batchFunc
+
+	mu      sync.Mutex
+	results map[string]*loadResult
+	pending []string
+}
+
+func newLoader(batch batchFunc) *loader {
+	return &loader{
+		batch:   batch,
+		results: map[string]*loadResult{},
+	}
+}
+
+func (l *loader) Load(key string) (interface{}, error) {
+	l.mu.Lock()
+	result, ok := l.results[key]
+	if !ok {
+		result = &loadResult{done: make(chan struct{})}
+		l.results[key] = result
+		l.pending = append(l.pending, key)
+		if len(l.pending) == 1 {
+			time.AfterFunc(batchWait, l.dispatch)
+		}
+	}
+	l.mu.Unlock()
+
+	<-result.done
+	return result.value, result.err
+}
+
+func (l *loader) dispatch() {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	values, err := l.batch(keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		result := l.results[key]
+		result.value, result.err = values[key], err
+		close(result.done)
+	}
+}
+
+// maxConcurrentLoads bounds the storage queries loadEach runs at once.
+const maxConcurrentLoads = 8
+
+// loadEach runs load for every key concurrently and collects the values by
+// key. It fails with the first error any load returns.
+func loadEach(keys []string, load func(key string) (interface{}, error)) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxConcurrentLoads)
+	for _, key := range keys {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			value, err := load(key)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			out[key] = value
+		}(key)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return out, nil
+}
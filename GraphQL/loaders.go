@@ -0,0 +1,92 @@
+package GraphQL
+
+import (
+	"context"
+	"github.com/jonathanpatta/apartmentservices/Orders"
+	"github.com/jonathanpatta/apartmentservices/Subscriptions"
+)
+
+// loaders are created for every request so a query walking the catalog
+// issues the storage queries of a level together instead of one after the
+// other.
+type loaders struct {
+	// services of a producer, keyed by producer SK.
+	services *loader
+	// items of a service, keyed by service SK.
+	items *loader
+	// every item of a producer, keyed by producer SK.
+	producerItems *loader
+	// orders of a consumer, keyed by consumer SK.
+	orders *loader
+	// subscriptions of a consumer, keyed by consumer SK.
+	subscriptions *loader
+}
+
+type loadersKey struct{}
+
+func (r *Resolver) withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		services:      newLoader(r.batchServices),
+		items:         newLoader(r.batchItems),
+		producerItems: newLoader(r.batchProducerItems),
+		orders:        newLoader(r.batchOrders),
+		subscriptions: newLoader(r.batchSubscriptions),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// batchServices queries the services of each producer.
+func (r *Resolver) batchServices(producerIds []string) (map[string]interface{}, error) {
+	return loadEach(producerIds, func(producerId string) (interface{}, error) {
+		return r.servicesCli.ListUnder(producerId)
+	})
+}
+
+// batchItems queries the listed items of each service.
+func (r *Resolver) batchItems(serviceIds []string) (map[string]interface{}, error) {
+	return loadEach(serviceIds, func(serviceId string) (interface{}, error) {
+		return r.itemsCli.ListUnder(serviceId)
+	})
+}
+
+// batchProducerItems queries every listed item of each producer.
+func (r *Resolver) batchProducerItems(producerIds []string) (map[string]interface{}, error) {
+	return loadEach(producerIds, func(producerId string) (interface{}, error) {
+		return r.itemsCli.ListUnder(producerId)
+	})
+}
+
+func (r *Resolver) batchOrders(consumerIds []string) (map[string]interface{}, error) {
+	return loadEach(consumerIds, func(consumerId string) (interface{}, error) {
+		orders, err := r.ordersCli.ListForConsumer(consumerId)
+		if err != nil {
+			return nil, err
+		}
+		var listed []*Orders.Order
+		for _, order := range orders {
+			if !order.IsDeleted {
+				listed = append(listed, order)
+			}
+		}
+		return listed, nil
+	})
+}
+
+func (r *Resolver) batchSubscriptions(consumerIds []string) (map[string]interface{}, error) {
+	return loadEach(consumerIds, func(consumerId string) (interface{}, error) {
+		subscriptions, err := r.subscriptionsCli.ListForConsumer(consumerId)
+		if err != nil {
+			return nil, err
+		}
+		var listed []*Subscriptions.Subscription
+		for _, subscription := range subscriptions {
+			if !subscription.IsDeleted {
+				listed = append(listed, subscription)
+			}
+		}
+		return listed, nil
+	})
+}
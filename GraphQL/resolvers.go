@@ -0,0 +1,298 @@
+package GraphQL
+
+import (
+	"context"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/jonathanpatta/apartmentservices/Consumers"
+	"github.com/jonathanpatta/apartmentservices/Items"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Orders"
+	"github.com/jonathanpatta/apartmentservices/Producers"
+	"github.com/jonathanpatta/apartmentservices/Services"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Subscriptions"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"net/http"
+)
+
+// Resolver is the root of the schema.
+type Resolver struct {
+	producersCli     *Producers.ProducerService
+	servicesCli      *Services.ServiceService
+	itemsCli         *Items.ItemService
+	consumersCli     *Consumers.ConsumerService
+	ordersCli        *Orders.OrderService
+	subscriptionsCli *Subscriptions.SubscriptionService
+}
+
+func NewResolver(settings *Settings.Settings) (*Resolver, error) {
+	producersCli, err := Producers.NewProducerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	servicesCli, err := Services.NewServiceService(settings)
+	if err != nil {
+		return nil, err
+	}
+	itemsCli, err := Items.NewItemService(settings)
+	if err != nil {
+		return nil, err
+	}
+	consumersCli, err := Consumers.NewConsumerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	ordersCli, err := Orders.NewOrderService(settings)
+	if err != nil {
+		return nil, err
+	}
+	subscriptionsCli, err := Subscriptions.NewSubscriptionService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resolver{
+		producersCli:     producersCli,
+		servicesCli:      servicesCli,
+		itemsCli:         itemsCli,
+		consumersCli:     consumersCli,
+		ordersCli:        ordersCli,
+		subscriptionsCli: subscriptionsCli,
+	}, nil
+}
+
+// resolverError reports the code of a typed error in the extensions of the
+// GraphQL error, hiding the message of internal errors.
+type resolverError struct {
+	message string
+	code    Utils.ErrorCode
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func toResolverError(ctx context.Context, err error) error {
+	code := Utils.ErrorCodeOf(err)
+	if code == Utils.Internal {
+		Logger.FromContext(ctx).Error("internal error", "error", err)
+		return &resolverError{message: http.StatusText(http.StatusInternalServerError), code: code}
+	}
+	return &resolverError{message: err.Error(), code: code}
+}
+
+func (r *Resolver) Producers(ctx context.Context) ([]*ProducerResolver, error) {
+	producers, err := r.producersCli.List()
+	if err != nil {
+		return nil, toResolverError(ctx, err)
+	}
+	out := make([]*ProducerResolver, len(producers))
+	for i, producer := range producers {
+		out[i] = &ProducerResolver{producer}
+	}
+	return out, nil
+}
+
+func (r *Resolver) Producer(ctx context.Context, args struct{ ID graphql.ID }) (*ProducerResolver, error) {
+	producer, err := r.producersCli.Read(string(args.ID))
+	if Utils.ErrorCodeOf(err) == Utils.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toResolverError(ctx, err)
+	}
+	if producer.IsDeleted || producer.Suspended {
+		return nil, nil
+	}
+	return &ProducerResolver{producer}, nil
+}
+
+func (r *Resolver) Consumer(ctx context.Context, args struct{ ID graphql.ID }) (*ConsumerResolver, error) {
+	consumer, err := r.consumersCli.Read(string(args.ID))
+	if Utils.ErrorCodeOf(err) == Utils.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toResolverError(ctx, err)
+	}
+	if consumer.IsDeleted {
+		return nil, nil
+	}
+	return &ConsumerResolver{consumer}, nil
+}
+
+type ProducerResolver struct {
+	p *Producers.Producer
+}
+
+func (r *ProducerResolver) ID() graphql.ID          { return graphql.ID(r.p.SK) }
+func (r *ProducerResolver) UserId() string          { return r.p.UserId }
+func (r *ProducerResolver) ApartmentNumber() string { return r.p.ApartmentNumber }
+func (r *ProducerResolver) Storefront() *StorefrontResolver {
+	return &StorefrontResolver{&r.p.Storefront}
+}
+func (r *ProducerResolver) CreatedAt() float64    { return float64(r.p.CreatedAt) }
+func (r *ProducerResolver) LastModified() float64 { return float64(r.p.LastModified) }
+
+func (r *ProducerResolver) Services(ctx context.Context) ([]*ServiceResolver, error) {
+	value, err := loadersFrom(ctx).services.Load(r.p.SK)
+	if err != nil {
+		return nil, toResolverError(ctx, err)
+	}
+	services, _ := value.([]*Services.Service)
+	out := make([]*ServiceResolver, len(services))
+	for i, service := range services {
+		out[i] = &ServiceResolver{service}
+	}
+	return out, nil
+}
+
+func (r *ProducerResolver) Items(ctx context.Context) ([]*ItemResolver, error) {
+	value, err := loadersFrom(ctx).producerItems.Load(r.p.SK)
+	if err != nil {
+		return nil, toResolverError(ctx, err)
+	}
+	return itemResolvers(value), nil
+}
+
+type ServiceResolver struct {
+	s *Services.Service
+}
+
+func (r *ServiceResolver) ID() graphql.ID        { return graphql.ID(r.s.SK) }
+func (r *ServiceResolver) Name() string          { return r.s.Name }
+func (r *ServiceResolver) CreatedAt() float64    { return float64(r.s.CreatedAt) }
+func (r *ServiceResolver) LastModified() float64 { return float64(r.s.LastModified) }
+
+func (r *ServiceResolver) Items(ctx context.Context) ([]*ItemResolver, error) {
+	value, err := loadersFrom(ctx).items.Load(r.s.SK)
+	if err != nil {
+		return nil, toResolverError(ctx, err)
+	}
+	return itemResolvers(value), nil
+}
+
+func itemResolvers(value interface{}) []*ItemResolver {
+	items, _ := value.([]*Items.Item)
+	out := make([]*ItemResolver, len(items))
+	for i, item := range items {
+		out[i] = &ItemResolver{item}
+	}
+	return out
+}
+
+type StorefrontResolver struct {
+	s *Producers.Storefront
+}
+
+func (r *StorefrontResolver) DisplayName() string { return r.s.DisplayName }
+func (r *StorefrontResolver) Bio() string         { return r.s.Bio }
+func (r *StorefrontResolver) LogoUrl() string     { return r.s.LogoUrl }
+func (r *StorefrontResolver) BannerUrl() string   { return r.s.BannerUrl }
+
+func (r *StorefrontResolver) Contact() []*ContactOptionResolver {
+	out := []*ContactOptionResolver{}
+	for _, option := range r.s.Contact {
+		if option != nil {
+			out = append(out, &ContactOptionResolver{option})
+		}
+	}
+	return out
+}
+
+func (r *StorefrontResolver) Tags() []string {
+	if r.s.Tags == nil {
+		return []string{}
+	}
+	return r.s.Tags
+}
+
+type ContactOptionResolver struct {
+	c *Producers.ContactOption
+}
+
+func (r *ContactOptionResolver) Kind() string    { return r.c.Kind }
+func (r *ContactOptionResolver) Value() string   { return r.c.Value }
+func (r *ContactOptionResolver) Preferred() bool { return r.c.Preferred }
+
+type ItemResolver struct {
+	i *Items.Item
+}
+
+func (r *ItemResolver) ID() graphql.ID        { return graphql.ID(r.i.SK) }
+func (r *ItemResolver) Name() string          { return r.i.Name }
+func (r *ItemResolver) Description() string   { return r.i.Description }
+func (r *ItemResolver) Price() float64        { return float64(r.i.Price) }
+func (r *ItemResolver) CreatedAt() float64    { return float64(r.i.CreatedAt) }
+func (r *ItemResolver) LastModified() float64 { return float64(r.i.LastModified) }
+
+func (r *ItemResolver) ImageUrls() []string {
+	if r.i.ImageUrls == nil {
+		return []string{}
+	}
+	return r.i.ImageUrls
+}
+
+type ConsumerResolver struct {
+	c *Consumers.Consumer
+}
+
+func (r *ConsumerResolver) ID() graphql.ID { return graphql.ID(r.c.SK) }
+func (r *ConsumerResolver) UserId() string { return r.c.UserId }
+
+func (r *ConsumerResolver) Orders(ctx context.Context) ([]*OrderResolver, error) {
+	value, err := loadersFrom(ctx).orders.Load(r.c.SK)
+	if err != nil {
+		return nil, toResolverError(ctx, err)
+	}
+	orders, _ := value.([]*Orders.Order)
+	out := make([]*OrderResolver, len(orders))
+	for i, order := range orders {
+		out[i] = &OrderResolver{order}
+	}
+	return out, nil
+}
+
+func (r *ConsumerResolver) Subscriptions(ctx context.Context) ([]*SubscriptionResolver, error) {
+	value, err := loadersFrom(ctx).subscriptions.Load(r.c.SK)
+	if err != nil {
+		return nil, toResolverError(ctx, err)
+	}
+	subscriptions, _ := value.([]*Subscriptions.Subscription)
+	out := make([]*SubscriptionResolver, len(subscriptions))
+	for i, subscription := range subscriptions {
+		out[i] = &SubscriptionResolver{subscription}
+	}
+	return out, nil
+}
+
+type OrderResolver struct {
+	o *Orders.Order
+}
+
+func (r *OrderResolver) ID() graphql.ID        { return graphql.ID(r.o.SK) }
+func (r *OrderResolver) ItemId() string        { return r.o.ItemId }
+func (r *OrderResolver) ItemName() string      { return r.o.ItemName }
+func (r *OrderResolver) Note() string          { return r.o.Note }
+func (r *OrderResolver) Completed() string     { return r.o.Completed }
+func (r *OrderResolver) CreatedByName() string { return r.o.CreatedByName }
+func (r *OrderResolver) CreatedAt() float64    { return float64(r.o.CreatedAt) }
+func (r *OrderResolver) LastModified() float64 { return float64(r.o.LastModified) }
+
+type SubscriptionResolver struct {
+	s *Subscriptions.Subscription
+}
+
+func (r *SubscriptionResolver) ID() graphql.ID        { return graphql.ID(r.s.SK) }
+func (r *SubscriptionResolver) ItemId() string        { return r.s.ItemId }
+func (r *SubscriptionResolver) ItemName() string      { return r.s.ItemName }
+func (r *SubscriptionResolver) Note() string          { return r.s.Note }
+func (r *SubscriptionResolver) RecurringType() string { return r.s.RecurringType }
+func (r *SubscriptionResolver) Cancelled() bool       { return r.s.Cancelled }
+func (r *SubscriptionResolver) CreatedByName() string { return r.s.CreatedByName }
+func (r *SubscriptionResolver) CreatedAt() float64    { return float64(r.s.CreatedAt) }
+func (r *SubscriptionResolver) LastModified() float64 { return float64(r.s.LastModified) }
@@ -0,0 +1,90 @@
+package GraphQL
+
+// Schema exposes the catalog and the orders of consumers. Int64 fields such
+// as timestamps and prices are Float since GraphQL Int is only 32 bits.
+const Schema = `
+schema {
+	query: Query
+}
+
+type Query {
+	producers: [Producer!]!
+	producer(id: ID!): Producer
+	consumer(id: ID!): Consumer
+}
+
+type Producer {
+	id: ID!
+	userId: String!
+	apartmentNumber: String!
+	storefront: Storefront!
+	createdAt: Float!
+	lastModified: Float!
+	services: [Service!]!
+	# Every listed item of the producer, including those not under a service.
+	items: [Item!]!
+}
+
+type Storefront {
+	displayName: String!
+	bio: String!
+	logoUrl: String!
+	bannerUrl: String!
+	contact: [ContactOption!]!
+	tags: [String!]!
+}
+
+type ContactOption {
+	kind: String!
+	value: String!
+	preferred: Boolean!
+}
+
+type Service {
+	id: ID!
+	name: String!
+	createdAt: Float!
+	lastModified: Float!
+	items: [Item!]!
+}
+
+type Item {
+	id: ID!
+	name: String!
+	description: String!
+	imageUrls: [String!]!
+	price: Float!
+	createdAt: Float!
+	lastModified: Float!
+}
+
+type Consumer {
+	id: ID!
+	userId: String!
+	orders: [Order!]!
+	subscriptions: [Subscription!]!
+}
+
+type Order {
+	id: ID!
+	itemId: String!
+	itemName: String!
+	note: String!
+	completed: String!
+	createdByName: String!
+	createdAt: Float!
+	lastModified: Float!
+}
+
+type Subscription {
+	id: ID!
+	itemId: String!
+	itemName: String!
+	note: String!
+	recurringType: String!
+	cancelled: Boolean!
+	createdByName: String!
+	createdAt: Float!
+	lastModified: Float!
+}
+`
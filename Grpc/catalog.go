@@ -0,0 +1,156 @@
+package Grpc
+
+import (
+	"context"
+	"github.com/jonathanpatta/apartmentservices/Grpc/pb"
+	"github.com/jonathanpatta/apartmentservices/Items"
+	"github.com/jonathanpatta/apartmentservices/Producers"
+	"github.com/jonathanpatta/apartmentservices/Services"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"strings"
+)
+
+type CatalogServer struct {
+	pb.UnimplementedCatalogServer
+
+	producersCli *Producers.ProducerService
+	servicesCli  *Services.ServiceService
+}
+
+func NewCatalogServer(settings *Settings.Settings) (*CatalogServer, error) {
+	producersCli, err := Producers.NewProducerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	servicesCli, err := Services.NewServiceService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CatalogServer{
+		producersCli: producersCli,
+		servicesCli:  servicesCli,
+	}, nil
+}
+
+func (s *CatalogServer) ListProducers(in *pb.ListProducersRequest, stream pb.Catalog_ListProducersServer) error {
+	producers, err := s.producersCli.List()
+	if err != nil {
+		return toStatus(stream.Context(), err)
+	}
+	for _, producer := range producers {
+		err = stream.Send(producerToPb(producer))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CatalogServer) GetProducer(ctx context.Context, in *pb.GetProducerRequest) (*pb.Producer, error) {
+	producer, err := s.producer(in.ProducerId)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return producerToPb(producer), nil
+}
+
+func (s *CatalogServer) ListServices(in *pb.ListServicesRequest, stream pb.Catalog_ListServicesServer) error {
+	producer, err := s.producer(in.ProducerId)
+	if err != nil {
+		return toStatus(stream.Context(), err)
+	}
+	services, err := s.producersCli.GetServices(producer.SK)
+	if err != nil {
+		return toStatus(stream.Context(), err)
+	}
+	for _, service := range services {
+		if service.IsDeleted {
+			continue
+		}
+		err = stream.Send(serviceToPb(service))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CatalogServer) ListItems(in *pb.ListItemsRequest, stream pb.Catalog_ListItemsServer) error {
+	producer, err := s.producer(in.ProducerId)
+	if err != nil {
+		return toStatus(stream.Context(), err)
+	}
+
+	var items []*Items.Item
+	if in.ServiceId == "" {
+		items, err = s.producersCli.GetAllItems(producer.SK)
+	} else {
+		var service *Services.Service
+		service, err = s.servicesCli.Read(in.ServiceId)
+		if err == nil && (service.IsDeleted || !strings.HasPrefix(service.SK, producer.SK+"_")) {
+			err = Utils.NewError(Utils.NotFound, "service %v not found", in.ServiceId)
+		}
+		if err == nil {
+			items, err = s.servicesCli.GetItems(service.SK)
+		}
+	}
+	if err != nil {
+		return toStatus(stream.Context(), err)
+	}
+
+	for _, item := range items {
+		if item.IsDeleted || item.Hidden {
+			continue
+		}
+		err = stream.Send(itemToPb(item))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// producer reads a producer that is still listed.
+func (s *CatalogServer) producer(producerId string) (*Producers.Producer, error) {
+	producer, err := s.producersCli.Read(producerId)
+	if err != nil {
+		return nil, err
+	}
+	if producer.IsDeleted || producer.Suspended {
+		return nil, Utils.NewError(Utils.NotFound, "producer %v not found", producerId)
+	}
+	return producer, nil
+}
+
+func producerToPb(in *Producers.Producer) *pb.Producer {
+	return &pb.Producer{
+		Id:              in.SK,
+		UserId:          in.UserId,
+		ApartmentNumber: in.ApartmentNumber,
+		CreatedAt:       in.CreatedAt,
+		LastModified:    in.LastModified,
+	}
+}
+
+func serviceToPb(in *Services.Service) *pb.Service {
+	return &pb.Service{
+		Id:           in.SK,
+		Name:         in.Name,
+		CreatedAt:    in.CreatedAt,
+		LastModified: in.LastModified,
+	}
+}
+
+func itemToPb(in *Items.Item) *pb.Item {
+	return &pb.Item{
+		Id:           in.SK,
+		Name:         in.Name,
+		Description:  in.Description,
+		ImageUrls:    in.ImageUrls,
+		Price:        in.Price,
+		CreatedAt:    in.CreatedAt,
+		LastModified: in.LastModified,
+	}
+}
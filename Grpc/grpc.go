@@ -0,0 +1,137 @@
+package Grpc
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jonathanpatta/apartmentservices/Admin"
+	"github.com/jonathanpatta/apartmentservices/Grpc/pb"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+var errorCodes = map[Utils.ErrorCode]codes.Code{
+	Utils.BadRequest:   codes.InvalidArgument,
+	Utils.Validation:   codes.InvalidArgument,
+	Utils.Unauthorized: codes.Unauthenticated,
+	Utils.Forbidden:    codes.PermissionDenied,
+	Utils.NotFound:     codes.NotFound,
+	Utils.Conflict:     codes.AlreadyExists,
+	Utils.Internal:     codes.Internal,
+
+	Utils.PreconditionFailed: codes.FailedPrecondition,
+	Utils.Unprocessable:      codes.InvalidArgument,
+}
+
+// NewServer registers the Catalog and Orders services on a gRPC server that
+// authenticates and logs every call like the http API does.
+func NewServer(settings *Settings.Settings) (*grpc.Server, error) {
+	Admin.EnforceSuspensions(settings)
+
+	catalog, err := NewCatalogServer(settings)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := NewOrdersServer(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &interceptors{auth: settings.MiddlewareService}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(i.unary),
+		grpc.ChainStreamInterceptor(i.stream),
+	)
+	pb.RegisterCatalogServer(server, catalog)
+	pb.RegisterOrdersServer(server, orders)
+	return server, nil
+}
+
+// toStatus turns a service error into a gRPC status, hiding the message of
+// internal errors like Middleware.WriteError does.
+func toStatus(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	code, ok := errorCodes[Utils.ErrorCodeOf(err)]
+	if !ok || code == codes.Internal {
+		Logger.FromContext(ctx).Error("internal error", "error", err)
+		return status.Error(codes.Internal, "Internal Server Error")
+	}
+	return status.Error(code, err.Error())
+}
+
+type interceptors struct {
+	auth *Middleware.MiddlwareService
+}
+
+// begin tags the call with a request id and authenticates it with the
+// Firebase token in the authorization metadata.
+func (i *interceptors) begin(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestId := firstOf(md, "x-request-id")
+	if requestId == "" || len(requestId) > 128 {
+		requestId = uuid.NewString()
+	}
+	ctx = Logger.NewContext(ctx, Logger.Default.With("request_id", requestId))
+
+	userCtx, err := i.auth.VerifyToken(ctx, firstOf(md, "authorization"))
+	if err != nil {
+		return ctx, toStatus(ctx, err)
+	}
+	return userCtx, nil
+}
+
+func (i *interceptors) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx, err := i.begin(ctx)
+	var resp interface{}
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	logCall(ctx, info.FullMethod, err, start)
+	return resp, err
+}
+
+func (i *interceptors) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, err := i.begin(ss.Context())
+	if err == nil {
+		err = handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+	logCall(ctx, info.FullMethod, err, start)
+	return err
+}
+
+func logCall(ctx context.Context, method string, err error, start time.Time) {
+	Logger.FromContext(ctx).Info("grpc call",
+		"method", method,
+		"code", status.Code(err).String(),
+		"latency_ms", float64(time.Since(start).Microseconds())/1000,
+	)
+}
+
+// contextStream hands the authenticated context to stream handlers.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func firstOf(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
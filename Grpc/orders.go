@@ -0,0 +1,117 @@
+package Grpc
+
+import (
+	"context"
+	"github.com/jonathanpatta/apartmentservices/Grpc/pb"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Orders"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+)
+
+type OrdersServer struct {
+	pb.UnimplementedOrdersServer
+
+	ordersCli *Orders.OrderService
+}
+
+func NewOrdersServer(settings *Settings.Settings) (*OrdersServer, error) {
+	ordersCli, err := Orders.NewOrderService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrdersServer{
+		ordersCli: ordersCli,
+	}, nil
+}
+
+func (s *OrdersServer) CreateOrder(ctx context.Context, in *pb.CreateOrderRequest) (*pb.Order, error) {
+	user := Middleware.GetFirebaseUser(ctx)
+	data := &Orders.Order{
+		ItemId:               in.ItemId,
+		ItemName:             in.ItemName,
+		Note:                 in.Note,
+		CreatedByName:        user.Name,
+		CreatedByUserId:      user.UserId,
+		CreatedByUserEmail:   user.Email,
+		CreatedByUserPicture: user.Picture,
+	}
+	err := Utils.Validate(data)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	order, err := s.ordersCli.Create(ctx, in.ConsumerId, data)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return orderToPb(order), nil
+}
+
+func (s *OrdersServer) GetOrder(ctx context.Context, in *pb.GetOrderRequest) (*pb.Order, error) {
+	order, err := s.order(in.OrderId)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return orderToPb(order), nil
+}
+
+func (s *OrdersServer) SetOrderStatus(ctx context.Context, in *pb.SetOrderStatusRequest) (*pb.Order, error) {
+	order, err := s.order(in.OrderId)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	order, err = s.ordersCli.SetStatus(ctx, order.SK, in.Completed, nil)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return orderToPb(order), nil
+}
+
+func (s *OrdersServer) ListOrders(in *pb.ListOrdersRequest, stream pb.Orders_ListOrdersServer) error {
+	if in.ConsumerId == "" {
+		return toStatus(stream.Context(), Utils.NewError(Utils.Validation, "consumer id required"))
+	}
+	orders, err := s.ordersCli.ListForConsumer(in.ConsumerId)
+	if err != nil {
+		return toStatus(stream.Context(), err)
+	}
+	for _, order := range orders {
+		if order.IsDeleted {
+			continue
+		}
+		err = stream.Send(orderToPb(order))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// order reads an order that has not been deleted.
+func (s *OrdersServer) order(orderId string) (*Orders.Order, error) {
+	order, err := s.ordersCli.Read(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.IsDeleted {
+		return nil, Utils.NewError(Utils.NotFound, "order %v not found", orderId)
+	}
+	return order, nil
+}
+
+func orderToPb(in *Orders.Order) *pb.Order {
+	return &pb.Order{
+		Id:              in.SK,
+		ItemId:          in.ItemId,
+		ItemName:        in.ItemName,
+		Note:            in.Note,
+		Completed:       in.Completed,
+		CreatedByUserId: in.CreatedByUserId,
+		CreatedByName:   in.CreatedByName,
+		CreatedAt:       in.CreatedAt,
+		LastModified:    in.LastModified,
+	}
+}
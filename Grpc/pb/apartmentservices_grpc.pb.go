@@ -0,0 +1,521 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: apartmentservices.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// CatalogClient is the client API for Catalog service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CatalogClient interface {
+	ListProducers(ctx context.Context, in *ListProducersRequest, opts ...grpc.CallOption) (Catalog_ListProducersClient, error)
+	GetProducer(ctx context.Context, in *GetProducerRequest, opts ...grpc.CallOption) (*Producer, error)
+	ListServices(ctx context.Context, in *ListServicesRequest, opts ...grpc.CallOption) (Catalog_ListServicesClient, error)
+	// ListItems streams the items of a service, or of every service of a
+	// producer when service_id is empty.
+	ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (Catalog_ListItemsClient, error)
+}
+
+type catalogClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCatalogClient(cc grpc.ClientConnInterface) CatalogClient {
+	return &catalogClient{cc}
+}
+
+func (c *catalogClient) ListProducers(ctx context.Context, in *ListProducersRequest, opts ...grpc.CallOption) (Catalog_ListProducersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Catalog_ServiceDesc.Streams[0], "/apartmentservices.v1.Catalog/ListProducers", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &catalogListProducersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Catalog_ListProducersClient interface {
+	Recv() (*Producer, error)
+	grpc.ClientStream
+}
+
+type catalogListProducersClient struct {
+	grpc.ClientStream
+}
+
+func (x *catalogListProducersClient) Recv() (*Producer, error) {
+	m := new(Producer)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *catalogClient) GetProducer(ctx context.Context, in *GetProducerRequest, opts ...grpc.CallOption) (*Producer, error) {
+	out := new(Producer)
+	err := c.cc.Invoke(ctx, "/apartmentservices.v1.Catalog/GetProducer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *catalogClient) ListServices(ctx context.Context, in *ListServicesRequest, opts ...grpc.CallOption) (Catalog_ListServicesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Catalog_ServiceDesc.Streams[1], "/apartmentservices.v1.Catalog/ListServices", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &catalogListServicesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Catalog_ListServicesClient interface {
+	Recv() (*Service, error)
+	grpc.ClientStream
+}
+
+type catalogListServicesClient struct {
+	grpc.ClientStream
+}
+
+func (x *catalogListServicesClient) Recv() (*Service, error) {
+	m := new(Service)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *catalogClient) ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (Catalog_ListItemsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Catalog_ServiceDesc.Streams[2], "/apartmentservices.v1.Catalog/ListItems", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &catalogListItemsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Catalog_ListItemsClient interface {
+	Recv() (*Item, error)
+	grpc.ClientStream
+}
+
+type catalogListItemsClient struct {
+	grpc.ClientStream
+}
+
+func (x *catalogListItemsClient) Recv() (*Item, error) {
+	m := new(Item)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CatalogServer is the server API for Catalog service.
+// All implementations must embed UnimplementedCatalogServer
+// for forward compatibility
+type CatalogServer interface {
+	ListProducers(*ListProducersRequest, Catalog_ListProducersServer) error
+	GetProducer(context.Context, *GetProducerRequest) (*Producer, error)
+	ListServices(*ListServicesRequest, Catalog_ListServicesServer) error
+	// ListItems streams the items of a service, or of every service of a
+	// producer when service_id is empty.
+	ListItems(*ListItemsRequest, Catalog_ListItemsServer) error
+	mustEmbedUnimplementedCatalogServer()
+}
+
+// UnimplementedCatalogServer must be embedded to have forward compatible implementations.
+type UnimplementedCatalogServer struct {
+}
+
+func (UnimplementedCatalogServer) ListProducers(*ListProducersRequest, Catalog_ListProducersServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListProducers not implemented")
+}
+func (UnimplementedCatalogServer) GetProducer(context.Context, *GetProducerRequest) (*Producer, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProducer not implemented")
+}
+func (UnimplementedCatalogServer) ListServices(*ListServicesRequest, Catalog_ListServicesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListServices not implemented")
+}
+func (UnimplementedCatalogServer) ListItems(*ListItemsRequest, Catalog_ListItemsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListItems not implemented")
+}
+func (UnimplementedCatalogServer) mustEmbedUnimplementedCatalogServer() {}
+
+// UnsafeCatalogServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CatalogServer will
+// result in compilation errors.
+type UnsafeCatalogServer interface {
+	mustEmbedUnimplementedCatalogServer()
+}
+
+func RegisterCatalogServer(s grpc.ServiceRegistrar, srv CatalogServer) {
+	s.RegisterService(&Catalog_ServiceDesc, srv)
+}
+
+func _Catalog_ListProducers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListProducersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CatalogServer).ListProducers(m, &catalogListProducersServer{stream})
+}
+
+type Catalog_ListProducersServer interface {
+	Send(*Producer) error
+	grpc.ServerStream
+}
+
+type catalogListProducersServer struct {
+	grpc.ServerStream
+}
+
+func (x *catalogListProducersServer) Send(m *Producer) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Catalog_GetProducer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProducerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatalogServer).GetProducer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apartmentservices.v1.Catalog/GetProducer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatalogServer).GetProducer(ctx, req.(*GetProducerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Catalog_ListServices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListServicesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CatalogServer).ListServices(m, &catalogListServicesServer{stream})
+}
+
+type Catalog_ListServicesServer interface {
+	Send(*Service) error
+	grpc.ServerStream
+}
+
+type catalogListServicesServer struct {
+	grpc.ServerStream
+}
+
+func (x *catalogListServicesServer) Send(m *Service) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Catalog_ListItems_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListItemsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CatalogServer).ListItems(m, &catalogListItemsServer{stream})
+}
+
+type Catalog_ListItemsServer interface {
+	Send(*Item) error
+	grpc.ServerStream
+}
+
+type catalogListItemsServer struct {
+	grpc.ServerStream
+}
+
+func (x *catalogListItemsServer) Send(m *Item) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Catalog_ServiceDesc is the grpc.ServiceDesc for Catalog service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Catalog_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "apartmentservices.v1.Catalog",
+	HandlerType: (*CatalogServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetProducer",
+			Handler:    _Catalog_GetProducer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListProducers",
+			Handler:       _Catalog_ListProducers_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListServices",
+			Handler:       _Catalog_ListServices_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListItems",
+			Handler:       _Catalog_ListItems_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "apartmentservices.proto",
+}
+
+// OrdersClient is the client API for Orders service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OrdersClient interface {
+	CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	SetOrderStatus(ctx context.Context, in *SetOrderStatusRequest, opts ...grpc.CallOption) (*Order, error)
+	ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (Orders_ListOrdersClient, error)
+}
+
+type ordersClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrdersClient(cc grpc.ClientConnInterface) OrdersClient {
+	return &ordersClient{cc}
+}
+
+func (c *ordersClient) CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	out := new(Order)
+	err := c.cc.Invoke(ctx, "/apartmentservices.v1.Orders/CreateOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ordersClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	out := new(Order)
+	err := c.cc.Invoke(ctx, "/apartmentservices.v1.Orders/GetOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ordersClient) SetOrderStatus(ctx context.Context, in *SetOrderStatusRequest, opts ...grpc.CallOption) (*Order, error) {
+	out := new(Order)
+	err := c.cc.Invoke(ctx, "/apartmentservices.v1.Orders/SetOrderStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ordersClient) ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (Orders_ListOrdersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Orders_ServiceDesc.Streams[0], "/apartmentservices.v1.Orders/ListOrders", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ordersListOrdersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Orders_ListOrdersClient interface {
+	Recv() (*Order, error)
+	grpc.ClientStream
+}
+
+type ordersListOrdersClient struct {
+	grpc.ClientStream
+}
+
+func (x *ordersListOrdersClient) Recv() (*Order, error) {
+	m := new(Order)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// OrdersServer is the server API for Orders service.
+// All implementations must embed UnimplementedOrdersServer
+// for forward compatibility
+type OrdersServer interface {
+	CreateOrder(context.Context, *CreateOrderRequest) (*Order, error)
+	GetOrder(context.Context, *GetOrderRequest) (*Order, error)
+	SetOrderStatus(context.Context, *SetOrderStatusRequest) (*Order, error)
+	ListOrders(*ListOrdersRequest, Orders_ListOrdersServer) error
+	mustEmbedUnimplementedOrdersServer()
+}
+
+// UnimplementedOrdersServer must be embedded to have forward compatible implementations.
+type UnimplementedOrdersServer struct {
+}
+
+func (UnimplementedOrdersServer) CreateOrder(context.Context, *CreateOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrder not implemented")
+}
+func (UnimplementedOrdersServer) GetOrder(context.Context, *GetOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedOrdersServer) SetOrderStatus(context.Context, *SetOrderStatusRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetOrderStatus not implemented")
+}
+func (UnimplementedOrdersServer) ListOrders(*ListOrdersRequest, Orders_ListOrdersServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListOrders not implemented")
+}
+func (UnimplementedOrdersServer) mustEmbedUnimplementedOrdersServer() {}
+
+// UnsafeOrdersServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrdersServer will
+// result in compilation errors.
+type UnsafeOrdersServer interface {
+	mustEmbedUnimplementedOrdersServer()
+}
+
+func RegisterOrdersServer(s grpc.ServiceRegistrar, srv OrdersServer) {
+	s.RegisterService(&Orders_ServiceDesc, srv)
+}
+
+func _Orders_CreateOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrdersServer).CreateOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apartmentservices.v1.Orders/CreateOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrdersServer).CreateOrder(ctx, req.(*CreateOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orders_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrdersServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apartmentservices.v1.Orders/GetOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrdersServer).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orders_SetOrderStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetOrderStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrdersServer).SetOrderStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apartmentservices.v1.Orders/SetOrderStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrdersServer).SetOrderStatus(ctx, req.(*SetOrderStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orders_ListOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListOrdersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrdersServer).ListOrders(m, &ordersListOrdersServer{stream})
+}
+
+type Orders_ListOrdersServer interface {
+	Send(*Order) error
+	grpc.ServerStream
+}
+
+type ordersListOrdersServer struct {
+	grpc.ServerStream
+}
+
+func (x *ordersListOrdersServer) Send(m *Order) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Orders_ServiceDesc is the grpc.ServiceDesc for Orders service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Orders_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "apartmentservices.v1.Orders",
+	HandlerType: (*OrdersServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateOrder",
+			Handler:    _Orders_CreateOrder_Handler,
+		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _Orders_GetOrder_Handler,
+		},
+		{
+			MethodName: "SetOrderStatus",
+			Handler:    _Orders_SetOrderStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListOrders",
+			Handler:       _Orders_ListOrders_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "apartmentservices.proto",
+}
@@ -0,0 +1,181 @@
+package Health
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOk          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// CheckTimeout bounds each dependency check so one hung dependency cannot
+// hold up the readiness probe.
+const CheckTimeout = 2 * time.Second
+
+// CacheTTL is how long a readiness report is reused, so that probes, which
+// need no auth, cannot make every request call the dependencies.
+const CacheTTL = 5 * time.Second
+
+// firebaseKeysUrl is where the keys Firebase signs ID tokens with are
+// published.
+const firebaseKeysUrl = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status string `json:"status"`
+	// Error says whether the check failed or timed out, the details are only
+	// logged.
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type HealthService struct {
+	checks   map[string]Check
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu        sync.Mutex
+	report    *Report
+	checkedAt time.Time
+}
+
+func NewHealthService(timeout time.Duration, cacheTTL time.Duration) *HealthService {
+	return &HealthService{
+		checks:   map[string]Check{},
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// NewSettingsHealthService checks the table and the files bucket configured
+// in settings, and that the Firebase token signing keys can be fetched.
+func NewSettingsHealthService(settings *Settings.Settings) *HealthService {
+	s := NewHealthService(CheckTimeout, CacheTTL)
+	s.AddCheck("table", func(ctx context.Context) error {
+		_, err := settings.Dynamo.Cli.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: settings.Dynamo.TableName,
+		})
+		return err
+	})
+	s.AddCheck("files_bucket", func(ctx context.Context) error {
+		_, err := settings.S3Settings.Cli.HeadBucket(ctx, &s3.HeadBucketInput{
+			Bucket: &settings.S3Settings.BucketName,
+		})
+		return err
+	})
+	s.AddCheck("firebase_keys", func(ctx context.Context) error {
+		if settings.FirebaseAuth == nil || settings.FirebaseAuth.Auth == nil {
+			return errors.New("firebase auth is not configured")
+		}
+		return checkUrl(ctx, firebaseKeysUrl)
+	})
+	return s
+}
+
+func (s *HealthService) AddCheck(name string, check Check) {
+	s.checks[name] = check
+}
+
+// Ready runs every check concurrently and reports each one. The report is
+// reused for the cache ttl, concurrent calls wait for the same run.
+func (s *HealthService) Ready(ctx context.Context) *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.report != nil && time.Since(s.checkedAt) < s.cacheTTL {
+		return s.report
+	}
+	s.report = s.check(ctx)
+	s.checkedAt = time.Now()
+	return s.report
+}
+
+func (s *HealthService) check(ctx context.Context) *Report {
+	report := &Report{
+		Status: StatusOk,
+		Checks: map[string]CheckResult{},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range s.checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := s.run(ctx, name, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOk {
+				report.Status = StatusUnavailable
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (s *HealthService) run(ctx context.Context, name string, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:    StatusOk,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		Logger.FromContext(ctx).Error("readiness check failed", "check", name, "error", err)
+		result.Status = StatusUnavailable
+		result.Error = "failed"
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = "timed out"
+		}
+	}
+	return result
+}
+
+func checkUrl(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("unexpected status " + resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package Health
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"log"
+	"net/http"
+)
+
+type HealthHttpService struct {
+	service *HealthService
+}
+
+func NewHealthHttpService(service *HealthService) (*HealthHttpService, error) {
+	return &HealthHttpService{
+		service: service,
+	}, nil
+}
+
+// Live answers as long as the process can serve requests.
+func (s *HealthHttpService) Live(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, r, &Report{Status: StatusOk})
+}
+
+func (s *HealthHttpService) Ready(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, r, s.service.Ready(r.Context()))
+}
+
+func writeReport(w http.ResponseWriter, r *http.Request, report *Report) {
+	outData, err := json.Marshal(report)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if report.Status != StatusOk {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("content-type", "application/json;charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(outData)
+}
+
+func addRoutes(r *mux.Router, server *HealthHttpService) {
+	r.HandleFunc("/healthz", server.Live).Methods("GET")
+	r.HandleFunc("/readyz", server.Ready).Methods("GET")
+}
+
+func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
+	server, err := NewHealthHttpService(NewSettingsHealthService(settings))
+	if err != nil {
+		log.Fatal(err)
+	}
+	addRoutes(r, server)
+}
+
+// NewUnavailableRouter serves only the health endpoints, with /readyz
+// reporting err. It lets the server stay up and tell the load balancer why
+// it is not ready when the settings could not be loaded.
+func NewUnavailableRouter(err error) *mux.Router {
+	service := NewHealthService(CheckTimeout, CacheTTL)
+	service.AddCheck("settings", func(context.Context) error {
+		return err
+	})
+	server := &HealthHttpService{service: service}
+
+	router := mux.NewRouter()
+	router.NotFoundHandler = Middleware.RequestIdMiddleware(Middleware.LoggingMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Middleware.WriteError(w, r, Utils.NewError(Utils.NotFound, "no route for %v %v", r.Method, r.URL.Path))
+		}),
+	))
+	router.Use(Middleware.RequestIdMiddleware)
+	router.Use(Middleware.LoggingMiddleware)
+	addRoutes(router, server)
+	return router
+}
@@ -0,0 +1,162 @@
+package Idempotency
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const IdempotencyPrefix = "IDEMPOTENCY#"
+
+// MaxKeyLength bounds the Idempotency-Key header.
+const MaxKeyLength = 255
+
+// Record holds the first response to a request made with an idempotency key.
+// It is keyed by the user and the key, so two users cannot collide.
+type Record struct {
+	PK string
+	SK string
+	// RequestHash identifies the request the key was first used with.
+	RequestHash string
+	Completed   bool
+
+	Status int
+	Header http.Header
+	Body   []byte
+
+	// ExpiresAt is when the record stops being replayed, in unix seconds. It
+	// doubles as the table's TTL attribute. Until the record is Completed it
+	// is the end of the lease of the request holding the key.
+	ExpiresAt int64
+}
+
+type IdempotencyService struct {
+	db               *dynamodb.Client
+	dynamodbSettings *Settings.DynamoDbSettings
+	retention        time.Duration
+	lease            time.Duration
+}
+
+func NewIdempotencyService(settings *Settings.Settings) (*IdempotencyService, error) {
+	return &IdempotencyService{
+		db:               settings.Dynamo.Cli,
+		dynamodbSettings: settings.Dynamo,
+		retention:        settings.IdempotencyRetention,
+		lease:            settings.IdempotencyLease,
+	}, nil
+}
+
+func recordKey(userId, key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: IdempotencyPrefix},
+		"SK": &types.AttributeValueMemberS{Value: userId + "_" + key},
+	}
+}
+
+// Begin claims key for the request with the given hash. It returns nil when
+// the request is the first to use the key and should be served, and the
+// completed record when it is a retry whose response should be replayed.
+// Retries of a request that is still being served fail with Conflict, and
+// reusing the key for a different request fails with Unprocessable.
+//
+// The claim only lasts for the lease, so when the process serving the request
+// dies before Complete or Release, a retry can take the key over once it ends.
+func (s *IdempotencyService) Begin(userId, key, requestHash string) (*Record, error) {
+	now := time.Now()
+	record := &Record{
+		PK:          IdempotencyPrefix,
+		SK:          userId + "_" + key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(s.lease).Unix(),
+	}
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:                item,
+		TableName:           s.dynamodbSettings.TableName,
+		ConditionExpression: aws.String("attribute_not_exists(SK) OR ExpiresAt < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.As(err, &conditionFailed) {
+		return nil, err
+	}
+
+	out, err := s.db.GetItem(context.Background(), &dynamodb.GetItemInput{
+		Key:            recordKey(userId, key),
+		TableName:      s.dynamodbSettings.TableName,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		// The record was released between the two calls, the client can
+		// retry straight away.
+		return nil, Utils.NewError(Utils.Conflict, "a request with this Idempotency-Key is already in progress")
+	}
+
+	var existing Record
+	err = attributevalue.UnmarshalMap(out.Item, &existing)
+	if err != nil {
+		return nil, err
+	}
+	if existing.RequestHash != requestHash {
+		return nil, Utils.NewError(Utils.Unprocessable, "Idempotency-Key was already used with a different request")
+	}
+	if !existing.Completed {
+		return nil, Utils.NewError(Utils.Conflict, "a request with this Idempotency-Key is already in progress")
+	}
+	return &existing, nil
+}
+
+// Complete stores the response to the request that claimed key, for Begin to
+// replay to retries until the retention ends.
+func (s *IdempotencyService) Complete(userId, key, requestHash string, status int, header http.Header, body []byte) error {
+	record := &Record{
+		PK:          IdempotencyPrefix,
+		SK:          userId + "_" + key,
+		RequestHash: requestHash,
+		Completed:   true,
+		Status:      status,
+		Header:      header,
+		Body:        body,
+		ExpiresAt:   time.Now().Add(s.retention).Unix(),
+	}
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	return err
+}
+
+// Release frees key so the request can be retried, for requests that failed
+// without creating anything.
+func (s *IdempotencyService) Release(userId, key string) error {
+	_, err := s.db.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+		Key:       recordKey(userId, key),
+		TableName: s.dynamodbSettings.TableName,
+	})
+	return err
+}
@@ -0,0 +1,124 @@
+package Idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"io"
+	"net/http"
+)
+
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders are the response headers stored with a record and sent
+// again when it is replayed.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// responseRecorder keeps a copy of the response it writes through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// requestHash identifies a request by its method, path and body.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Handler makes next safe to retry. A request carrying an Idempotency-Key is
+// served once per user and key, and retries with the same key and payload get
+// the stored response back instead of running next again. Responses with a
+// 5xx status are not stored, so the request can be retried. It must run after
+// ValidateToken.
+func (s *IdempotencyService) Handler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(KeyHeader)
+		if key == "" || r.Method == "OPTIONS" {
+			next(w, r)
+			return
+		}
+		if len(key) > MaxKeyLength {
+			Middleware.WriteError(w, r, Utils.NewError(Utils.BadRequest, "%v must be at most %v characters", KeyHeader, MaxKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			Middleware.WriteError(w, r, Utils.WrapError(Utils.BadRequest, err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userId := Middleware.GetFirebaseUser(r.Context()).UserId
+		hash := requestHash(r, body)
+
+		record, err := s.Begin(userId, key, hash)
+		if err != nil {
+			Middleware.WriteError(w, r, err)
+			return
+		}
+		if record != nil {
+			for _, name := range replayedHeaders {
+				if value := record.Header.Get(name); value != "" {
+					w.Header().Set(name, value)
+				}
+			}
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(record.Status)
+			w.Write(record.Body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next(recorder, r)
+
+		logger := Logger.FromContext(r.Context())
+		if recorder.status == 0 || recorder.status >= 500 {
+			err = s.Release(userId, key)
+			if err != nil {
+				logger.Error("releasing idempotency key failed", "error", err)
+			}
+			return
+		}
+
+		header := http.Header{}
+		for _, name := range replayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+		err = s.Complete(userId, key, hash, recorder.status, header, recorder.body.Bytes())
+		if err != nil {
+			// Leaving the key claimed would block retries until it expires.
+			logger.Error("storing idempotent response failed", "error", err)
+			if err = s.Release(userId, key); err != nil {
+				logger.Error("releasing idempotency key failed", "error", err)
+			}
+		}
+	}
+}
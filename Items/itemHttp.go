@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
 	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
 	"log"
 	"net/http"
 )
@@ -26,9 +28,9 @@ func NewItemHttpService(settings *Settings.Settings) (*ItemHttpService, error) {
 
 func (s *ItemHttpService) Create(w http.ResponseWriter, r *http.Request) {
 	var data Item
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
@@ -36,19 +38,19 @@ func (s *ItemHttpService) Create(w http.ResponseWriter, r *http.Request) {
 
 	item, err := s.service.Create(serviceId, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(item)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -60,72 +62,89 @@ func (s *ItemHttpService) Read(w http.ResponseWriter, r *http.Request) {
 
 	item, err := s.service.Read(data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	if Middleware.NotModified(w, r, Utils.ETagOf(item)) {
 		return
 	}
 
 	outData, err := json.Marshal(item)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ItemHttpService) Update(w http.ResponseWriter, r *http.Request) {
 
 	var data Item
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		current, err := s.service.Read(data.SK)
+		if err != nil {
+			return nil, err
+		}
+		return &current.Meta, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	item, err := s.service.Update(&data)
+	item, err := s.service.Update(&data, ifVersion)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", Utils.ETagOf(item))
 
 	outData, err := json.Marshal(item)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ItemHttpService) Delete(w http.ResponseWriter, r *http.Request) {
 	var data string
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	item, err := s.service.Delete(data)
+	item, err := s.service.Delete(data, nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(item)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -133,20 +152,15 @@ func (s *ItemHttpService) List(w http.ResponseWriter, r *http.Request) {
 
 	item, err := s.service.List()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	outData, err := json.Marshal(item)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if Middleware.NotModified(w, r, Utils.ETagOf(item)) {
 		return
 	}
 
-	_, err = fmt.Fprint(w, string(outData))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	Middleware.WriteJson(w, r, http.StatusOK, item)
 }
 
 func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
@@ -2,12 +2,11 @@ package Items
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/jonathanpatta/apartmentservices/Settings"
 	"github.com/jonathanpatta/apartmentservices/Utils"
 )
@@ -23,10 +22,11 @@ const ItemPrefix = "ITEM#"
 
 type Item struct {
 	Utils.Meta
-	Name        string   `json:"name,omitempty"`
-	Description string   `json:"description,omitempty"`
-	ImageUrls   []string `json:"image_urls,omitempty"`
-	Price       int64    `json:"price,omitempty"`
+	Name        string   `json:"name,omitempty" validate:"required,max=100"`
+	Description string   `json:"description,omitempty" validate:"max=2000"`
+	ImageUrls   []string `json:"image_urls,omitempty" validate:"max=10"`
+	Price       int64    `json:"price,omitempty" validate:"min=0"`
+	Hidden      bool     `json:"hidden,omitempty"`
 }
 
 type ItemService struct {
@@ -101,13 +101,13 @@ func (s *ItemService) Read(itemId string) (*Item, error) {
 		return nil, err
 	}
 	if len(data) != 1 {
-		return nil, errors.New(fmt.Sprintf("length of return items not 1 got %v", len(data)))
+		return nil, Utils.NotFoundOrAmbiguous("item", itemId, len(data))
 	}
 
 	return &data[0], nil
 }
 
-func (s *ItemService) Update(in *Item) (*Item, error) {
+func (s *ItemService) Update(in *Item, ifVersion *int64) (*Item, error) {
 
 	prevItem, err := s.Read(in.SK)
 	if err != nil {
@@ -125,10 +125,7 @@ func (s *ItemService) Update(in *Item) (*Item, error) {
 		return nil, err
 	}
 
-	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
-		Item:      item,
-		TableName: s.dynamodbSettings.TableName,
-	})
+	err = s.dynamodbSettings.PutVersioned(item, ifVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -141,11 +138,41 @@ func (s *ItemService) Update(in *Item) (*Item, error) {
 	return in, nil
 }
 
+// SetHidden hides the item from every catalog listing or makes it visible
+// again.
+func (s *ItemService) SetHidden(itemId string, hidden bool) (*Item, error) {
+	item, err := s.Read(itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Hidden = hidden
+	item.SetLastModifiedNow()
+
+	data, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      data,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
 func (s *ItemService) List() ([]*Item, error) {
 
 	keyFilter := expression.Key("PK").Equal(expression.Value(ItemPrefix))
 
-	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).Build()
+	filter := expression.Name("Hidden").NotEqual(expression.Value(true)).
+		And(expression.Name("IsDeleted").NotEqual(expression.Value(true)))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
 	if err != nil {
 		return nil, err
 	}
@@ -170,8 +197,111 @@ func (s *ItemService) List() ([]*Item, error) {
 	return data, nil
 }
 
-func (s *ItemService) Delete(itemId string) (*Item, error) {
-	return nil, nil
+// ListUnder returns the listed items created under a producer or service,
+// leaving out hidden and deleted ones, reading every page of the query.
+func (s *ItemService) ListUnder(parentId string) ([]*Item, error) {
+	keyFilter := expression.Key("PK").Equal(expression.Value(ItemPrefix)).
+		And(expression.Key("SK").BeginsWith(parentId + "_"))
+
+	filter := expression.Name("Hidden").NotEqual(expression.Value(true)).
+		And(expression.Name("IsDeleted").NotEqual(expression.Value(true)))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(s.db, &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+	})
+
+	data := []*Item{}
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		var page []*Item
+		err = attributevalue.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, page...)
+	}
+	return data, nil
+}
+
+func (s *ItemService) Delete(itemId string, ifVersion *int64) (*Item, error) {
+	item, err := s.Read(itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	item.IsDeleted = true
+	item.SetLastModifiedNow()
+
+	data, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.dynamodbSettings.PutVersioned(data, ifVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// ReadMany reads several items at once, keyed by id. Ids that do not exist
+// are left out.
+func (s *ItemService) ReadMany(itemIds []string) (map[string]*Item, error) {
+	records, err := s.dynamodbSettings.BatchGet(ItemPrefix, itemIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []*Item
+	err = attributevalue.UnmarshalListOfMaps(records, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*Item, len(data))
+	for _, item := range data {
+		out[item.SK] = item
+	}
+	return out, nil
+}
+
+// PutMany writes several items in transactions. Like Update, a item with
+// an ifVersion is only written while the stored one is still at that
+// version. The returned errors line up with items and are nil for the ones
+// that were written.
+func (s *ItemService) PutMany(items []*Item, ifVersions []*int64) []error {
+	errs := make([]error, len(items))
+	var records []map[string]types.AttributeValue
+	var versions []*int64
+	var indexes []int
+	for i, item := range items {
+		record, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		records = append(records, record)
+		versions = append(versions, ifVersions[i])
+		indexes = append(indexes, i)
+	}
+
+	for i, err := range s.dynamodbSettings.PutVersionedMany(records, versions) {
+		errs[indexes[i]] = err
+	}
+	return errs
 }
 
 func (s *ItemService) ServiceCheck(serviceId string) error {
@@ -200,7 +330,7 @@ func (s *ItemService) ServiceCheck(serviceId string) error {
 		return err
 	}
 	if len(data) != 1 {
-		return errors.New(fmt.Sprintf("length of return items not 1 got %v", len(data)))
+		return Utils.NotFoundOrAmbiguous("service", serviceId, len(data))
 	}
 	return nil
 }
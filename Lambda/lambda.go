@@ -0,0 +1,180 @@
+package Lambda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/gorillamux"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Event sources the handler can tell apart.
+const (
+	SourceAPIGatewayV1 = "apigateway-v1"
+	SourceAPIGatewayV2 = "apigateway-v2"
+	SourceALB          = "alb"
+)
+
+// Handler serves the router to whatever invokes the function: API Gateway
+// REST APIs and HTTP APIs with payload version 1.0, HTTP APIs with payload
+// version 2.0, Lambda function URLs, which share the 2.0 format, and ALB
+// target groups. It implements lambda.Handler. The events directory holds a
+// recorded request of each kind.
+//
+// Bodies that are not utf-8 are returned base64 encoded and marked so. The
+// router does not compress responses, that is left to API Gateway or
+// CloudFront, so json bodies go out as plain text whatever binary media types
+// a REST API lists.
+type Handler struct {
+	v1  *gorillamux.GorillaMuxAdapter
+	v2  *gorillamux.GorillaMuxAdapterV2
+	alb *gorillamux.GorillaMuxAdapterALB
+}
+
+func NewHandler(router *mux.Router) *Handler {
+	return &Handler{
+		v1:  gorillamux.New(router),
+		v2:  gorillamux.NewV2(router),
+		alb: gorillamux.NewALB(router),
+	}
+}
+
+// probe holds the fields that tell the event sources apart.
+type probe struct {
+	Version        string `json:"version"`
+	HTTPMethod     string `json:"httpMethod"`
+	RequestContext struct {
+		Elb *struct{} `json:"elb"`
+	} `json:"requestContext"`
+}
+
+// Source names the event source of payload.
+func Source(payload []byte) (string, error) {
+	var p probe
+	err := json.Unmarshal(payload, &p)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case p.RequestContext.Elb != nil:
+		return SourceALB, nil
+	case p.Version == "2.0":
+		return SourceAPIGatewayV2, nil
+	case p.HTTPMethod != "":
+		return SourceAPIGatewayV1, nil
+	}
+	return "", fmt.Errorf("unsupported lambda event, expected an API Gateway, function URL or ALB request")
+}
+
+func (h *Handler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	source, err := Source(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch source {
+	case SourceALB:
+		var req events.ALBTargetGroupRequest
+		err = json.Unmarshal(payload, &req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := h.ServeALB(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resp)
+	case SourceAPIGatewayV2:
+		var req events.APIGatewayV2HTTPRequest
+		err = json.Unmarshal(payload, &req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := h.v2.ProxyWithContext(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resp)
+	default:
+		var req events.APIGatewayProxyRequest
+		err = json.Unmarshal(payload, &req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := h.v1.ProxyWithContext(ctx, *core.NewSwitchableAPIGatewayRequestV1(&req))
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resp.Version1())
+	}
+}
+
+// ServeALB serves an ALB request, answering in the header format the target
+// group sends: multi-value headers when it has them enabled, single values
+// otherwise.
+func (h *Handler) ServeALB(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	multiValue := req.MultiValueHeaders != nil
+
+	// ALB passes query strings on still percent-encoded, while the adapter
+	// encodes them again.
+	req.QueryStringParameters = unescapeQuery(req.QueryStringParameters)
+	if req.MultiValueQueryStringParameters != nil {
+		query := map[string][]string{}
+		for name, values := range req.MultiValueQueryStringParameters {
+			name = queryUnescape(name)
+			for _, value := range values {
+				query[name] = append(query[name], queryUnescape(value))
+			}
+		}
+		req.MultiValueQueryStringParameters = query
+	}
+	if multiValue && req.Headers == nil {
+		// The adapter builds the request url from the single-value host.
+		req.Headers = map[string]string{}
+		for name, values := range req.MultiValueHeaders {
+			if len(values) > 0 {
+				req.Headers[strings.ToLower(name)] = values[0]
+			}
+		}
+	}
+
+	resp, err := h.alb.ProxyWithContext(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.StatusDescription = strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode)
+	if !multiValue {
+		resp.Headers = map[string]string{}
+		for name, values := range resp.MultiValueHeaders {
+			resp.Headers[name] = strings.Join(values, ", ")
+		}
+		resp.MultiValueHeaders = nil
+	}
+	return resp, nil
+}
+
+func unescapeQuery(query map[string]string) map[string]string {
+	if query == nil {
+		return nil
+	}
+	unescaped := map[string]string{}
+	for name, value := range query {
+		unescaped[queryUnescape(name)] = queryUnescape(value)
+	}
+	return unescaped
+}
+
+func queryUnescape(s string) string {
+	unescaped, err := url.QueryUnescape(s)
+	if err != nil {
+		return s
+	}
+	return unescaped
+}
@@ -0,0 +1,143 @@
+package Lambda
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// stubRouter answers the recorded events with what the adapter passed on,
+// and with a header holding two values. It runs the middlewares that wrap
+// the response writer, to check that they do not make it look like it can
+// stream.
+func stubRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.Use(Middleware.RequestIdMiddleware)
+	router.Use(Middleware.LoggingMiddleware)
+	router.Use(Middleware.MetricsMiddleware)
+	router.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, flusher := w.(http.Flusher)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("X-Stub", "a")
+		w.Header().Add("X-Stub", "b")
+		json.NewEncoder(w).Encode(map[string]string{
+			"method":  r.Method,
+			"probe":   r.URL.Query().Get("probe"),
+			"host":    r.Host,
+			"flusher": strconv.FormatBool(flusher),
+		})
+	}).Methods("GET")
+	return router
+}
+
+// testResponse holds the fields of the responses of every source.
+type testResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+func TestInvokeRecordedEvents(t *testing.T) {
+	tests := []struct {
+		file   string
+		source string
+		host   string
+		// multiValue is whether the response must carry multi-value
+		// headers, as v1 and multi-value ALB targets expect.
+		multiValue bool
+		// singleValue is whether the response must carry single-value
+		// headers.
+		singleValue bool
+		// joined is the single-value X-Stub header.
+		joined string
+		// statusDescription is only sent to ALB.
+		statusDescription string
+	}{
+		{"apigateway-v1.json", SourceAPIGatewayV1, "abc123defg.execute-api.ap-south-1.amazonaws.com", true, false, "", ""},
+		{"apigateway-v2.json", SourceAPIGatewayV2, "h1j2k3l4m5.execute-api.ap-south-1.amazonaws.com", false, true, "a,b", ""},
+		{"function-url.json", SourceAPIGatewayV2, "q7w8e9r0t1y2u3i4o5p6a7s8d9f0g1h2.lambda-url.ap-south-1.on.aws", false, true, "a,b", ""},
+		{"alb.json", SourceALB, "apartment-services-1234567890.ap-south-1.elb.amazonaws.com", false, true, "a, b", "200 OK"},
+		{"alb-multi-value.json", SourceALB, "apartment-services-1234567890.ap-south-1.elb.amazonaws.com", true, false, "", "200 OK"},
+	}
+
+	handler := NewHandler(stubRouter())
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			payload, err := os.ReadFile(filepath.Join("events", test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			source, err := Source(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if source != test.source {
+				t.Fatalf("source = %v, want %v", source, test.source)
+			}
+
+			out, err := handler.Invoke(context.Background(), payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var resp testResponse
+			err = json.Unmarshal(out, &resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("statusCode = %v, want 200", resp.StatusCode)
+			}
+			if resp.StatusDescription != test.statusDescription {
+				t.Errorf("statusDescription = %q, want %q", resp.StatusDescription, test.statusDescription)
+			}
+			if resp.IsBase64Encoded {
+				t.Errorf("json body is base64 encoded")
+			}
+
+			var body map[string]string
+			err = json.Unmarshal([]byte(resp.Body), &body)
+			if err != nil {
+				t.Fatalf("body %q: %v", resp.Body, err)
+			}
+			want := map[string]string{"method": "GET", "probe": "app start", "host": test.host, "flusher": "false"}
+			for name, value := range want {
+				if body[name] != value {
+					t.Errorf("body %v = %q, want %q", name, body[name], value)
+				}
+			}
+
+			if test.multiValue {
+				values := resp.MultiValueHeaders["X-Stub"]
+				if len(values) != 2 || values[0] != "a" || values[1] != "b" {
+					t.Errorf("multiValueHeaders X-Stub = %v, want [a b]", values)
+				}
+			}
+			if test.singleValue {
+				if resp.MultiValueHeaders != nil && test.source == SourceALB {
+					t.Errorf("single-value ALB response has multiValueHeaders %v", resp.MultiValueHeaders)
+				}
+				if resp.Headers["X-Stub"] != test.joined {
+					t.Errorf("headers X-Stub = %q, want %q", resp.Headers["X-Stub"], test.joined)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceRejectsOtherEvents(t *testing.T) {
+	_, err := Source([]byte(`{"Records": [{"eventSource": "aws:sqs"}]}`))
+	if err == nil {
+		t.Fatal("expected an error for an sqs event")
+	}
+}
@@ -0,0 +1,178 @@
+package Lambda
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// localTargetGroup stands in for the target group of ALB events built by
+// NewEvent.
+const localTargetGroup = "arn:aws:elasticloadbalancing:local:000000000000:targetgroup/local/0000000000000000"
+
+// NewEvent builds the event the given source would send the function for r,
+// so the handler can be driven by plain http requests locally.
+func NewEvent(source string, r *http.Request) ([]byte, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	body, isBase64 := string(data), false
+	if !utf8.Valid(data) {
+		body, isBase64 = base64.StdEncoding.EncodeToString(data), true
+	}
+
+	sourceIp, _, _ := net.SplitHostPort(r.RemoteAddr)
+	requestId := uuid.New().String()
+	now := time.Now()
+	query := r.URL.Query()
+
+	headers := map[string]string{}
+	multiValueHeaders := map[string][]string{}
+	for name, values := range r.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+		multiValueHeaders[strings.ToLower(name)] = values
+	}
+	headers["host"] = r.Host
+	multiValueHeaders["host"] = []string{r.Host}
+
+	var event interface{}
+	switch source {
+	case SourceAPIGatewayV1:
+		singleQuery := map[string]string{}
+		for name, values := range query {
+			singleQuery[name] = values[len(values)-1]
+		}
+		event = events.APIGatewayProxyRequest{
+			Resource:                        "/{proxy+}",
+			Path:                            r.URL.Path,
+			HTTPMethod:                      r.Method,
+			Headers:                         headers,
+			MultiValueHeaders:               multiValueHeaders,
+			QueryStringParameters:           singleQuery,
+			MultiValueQueryStringParameters: query,
+			PathParameters:                  map[string]string{"proxy": strings.TrimPrefix(r.URL.Path, "/")},
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID:        requestId,
+				Stage:            "local",
+				ResourcePath:     "/{proxy+}",
+				HTTPMethod:       r.Method,
+				Path:             r.URL.Path,
+				Protocol:         r.Proto,
+				RequestTimeEpoch: now.UnixNano() / int64(time.Millisecond),
+				Identity: events.APIGatewayRequestIdentity{
+					SourceIP:  sourceIp,
+					UserAgent: r.UserAgent(),
+				},
+			},
+			Body:            body,
+			IsBase64Encoded: isBase64,
+		}
+	case SourceAPIGatewayV2:
+		// Payload 2.0 moves cookies out of the headers.
+		delete(headers, "cookie")
+		var cookies []string
+		for _, cookie := range r.Cookies() {
+			cookies = append(cookies, cookie.String())
+		}
+		singleQuery := map[string]string{}
+		for name, values := range query {
+			singleQuery[name] = strings.Join(values, ",")
+		}
+		event = events.APIGatewayV2HTTPRequest{
+			Version:               "2.0",
+			RouteKey:              "$default",
+			RawPath:               r.URL.Path,
+			RawQueryString:        r.URL.RawQuery,
+			Cookies:               cookies,
+			Headers:               headers,
+			QueryStringParameters: singleQuery,
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				RouteKey:   "$default",
+				Stage:      "$default",
+				RequestID:  requestId,
+				DomainName: r.Host,
+				TimeEpoch:  now.UnixNano() / int64(time.Millisecond),
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+					Method:    r.Method,
+					Path:      r.URL.Path,
+					Protocol:  r.Proto,
+					SourceIP:  sourceIp,
+					UserAgent: r.UserAgent(),
+				},
+			},
+			Body:            body,
+			IsBase64Encoded: isBase64,
+		}
+	case SourceALB:
+		// ALB leaves query strings percent-encoded.
+		escapedQuery := map[string]string{}
+		for name, values := range query {
+			escapedQuery[url.QueryEscape(name)] = url.QueryEscape(values[len(values)-1])
+		}
+		event = events.ALBTargetGroupRequest{
+			HTTPMethod:            r.Method,
+			Path:                  r.URL.Path,
+			QueryStringParameters: escapedQuery,
+			Headers:               headers,
+			RequestContext: events.ALBTargetGroupRequestContext{
+				ELB: events.ELBContext{TargetGroupArn: localTargetGroup},
+			},
+			Body:            body,
+			IsBase64Encoded: isBase64,
+		}
+	default:
+		return nil, fmt.Errorf("unknown event source %q, expected %v, %v or %v", source, SourceAPIGatewayV1, SourceAPIGatewayV2, SourceALB)
+	}
+	return json.Marshal(event)
+}
+
+// response holds the fields shared by the responses of every source.
+type response struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	Cookies           []string            `json:"cookies"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// WriteResponse writes a response returned by Handler.Invoke to w, the way
+// API Gateway or ALB would pass it on to the client.
+func WriteResponse(w http.ResponseWriter, payload []byte) error {
+	var resp response
+	err := json.Unmarshal(payload, &resp)
+	if err != nil {
+		return err
+	}
+
+	body := []byte(resp.Body)
+	if resp.IsBase64Encoded {
+		body, err = base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, value := range resp.Headers {
+		w.Header().Set(name, value)
+	}
+	for name, values := range resp.MultiValueHeaders {
+		w.Header()[http.CanonicalHeaderKey(name)] = values
+	}
+	for _, cookie := range resp.Cookies {
+		w.Header().Add("Set-Cookie", cookie)
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, err = w.Write(body)
+	return err
+}
@@ -0,0 +1,108 @@
+package Logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Logger writes one json object per line. Fields set on a logger are added to
+// every entry it writes.
+type Logger struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	fields map[string]interface{}
+}
+
+// Default is used outside of a request and when a context carries no logger.
+var Default = New(os.Stdout)
+
+func New(out io.Writer) *Logger {
+	return &Logger{
+		mu:     &sync.Mutex{},
+		out:    out,
+		fields: map[string]interface{}{},
+	}
+}
+
+// With returns a copy of the logger with an extra field.
+func (l *Logger) With(key string, value interface{}) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fields := make(map[string]interface{}, len(l.fields)+1)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fields[key] = value
+	return &Logger{mu: l.mu, out: l.out, fields: fields}
+}
+
+// Set adds a field to the logger itself, so it also shows up on entries
+// written by code that got the logger earlier. It does nothing on Default,
+// which is shared by every request without a logger of its own; use With
+// there.
+func (l *Logger) Set(key string, value interface{}) {
+	if l == Default {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fields[key] = value
+}
+
+func (l *Logger) Info(msg string, keyValues ...interface{}) {
+	l.write("info", msg, keyValues)
+}
+
+func (l *Logger) Warn(msg string, keyValues ...interface{}) {
+	l.write("warn", msg, keyValues)
+}
+
+func (l *Logger) Error(msg string, keyValues ...interface{}) {
+	l.write("error", msg, keyValues)
+}
+
+func (l *Logger) write(level string, msg string, keyValues []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := make(map[string]interface{}, len(l.fields)+len(keyValues)/2+3)
+	for k, v := range l.fields {
+		entry[k] = v
+	}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		value := keyValues[i+1]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		entry[fmt.Sprint(keyValues[i])] = value
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level
+	entry["msg"] = msg
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(map[string]string{"level": "error", "msg": "could not encode log entry", "error": err.Error()})
+	}
+	l.out.Write(append(line, '\n'))
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the request logger, or Default when there is none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return Default
+}
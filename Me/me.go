@@ -0,0 +1,334 @@
+package Me
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/jonathanpatta/apartmentservices/Consumers"
+	"github.com/jonathanpatta/apartmentservices/Files"
+	"github.com/jonathanpatta/apartmentservices/Items"
+	"github.com/jonathanpatta/apartmentservices/Messages"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Orders"
+	"github.com/jonathanpatta/apartmentservices/Producers"
+	"github.com/jonathanpatta/apartmentservices/Services"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Subscriptions"
+	"path"
+)
+
+const (
+	ConsumerRole = "consumer"
+	ProducerRole = "producer"
+)
+
+type Profile struct {
+	User                Middleware.FirebaseUser `json:"user"`
+	Consumer            *Consumers.Consumer     `json:"consumer,omitempty"`
+	Producer            *Producers.Producer     `json:"producer,omitempty"`
+	Roles               []string                `json:"roles"`
+	OpenOrders          int                     `json:"open_orders"`
+	ActiveSubscriptions int                     `json:"active_subscriptions"`
+}
+
+type MeService struct {
+	consumersCli     *Consumers.ConsumerService
+	producersCli     *Producers.ProducerService
+	ordersCli        *Orders.OrderService
+	subscriptionsCli *Subscriptions.SubscriptionService
+	messagesCli      *Messages.MessageService
+	filesCli         *Files.S3FileService
+	createProfiles   bool
+}
+
+// Export is everything stored about a user, as bundled by MeService.Export.
+type Export struct {
+	User          Middleware.FirebaseUser       `json:"user"`
+	Consumer      *Consumers.Consumer           `json:"consumer,omitempty"`
+	Producer      *Producers.Producer           `json:"producer,omitempty"`
+	Services      []*Services.Service           `json:"services,omitempty"`
+	Items         []*Items.Item                 `json:"items,omitempty"`
+	Orders        []*Orders.Order               `json:"orders,omitempty"`
+	Subscriptions []*Subscriptions.Subscription `json:"subscriptions,omitempty"`
+	Messages      []*Messages.Message           `json:"messages,omitempty"`
+	Files         []string                      `json:"files,omitempty"`
+}
+
+type ErasureResult struct {
+	Consumer      bool `json:"consumer"`
+	Producer      bool `json:"producer"`
+	Orders        int  `json:"orders"`
+	Subscriptions int  `json:"subscriptions"`
+	Messages      int  `json:"messages"`
+	Files         int  `json:"files"`
+}
+
+func NewMeService(settings *Settings.Settings) (*MeService, error) {
+	consumersCli, err := Consumers.NewConsumerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	producersCli, err := Producers.NewProducerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	ordersCli, err := Orders.NewOrderService(settings)
+	if err != nil {
+		return nil, err
+	}
+	subscriptionsCli, err := Subscriptions.NewSubscriptionService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	messagesCli, err := Messages.NewMessageService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	filesCli, err := Files.NewS3FileService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MeService{
+		consumersCli:     consumersCli,
+		producersCli:     producersCli,
+		ordersCli:        ordersCli,
+		subscriptionsCli: subscriptionsCli,
+		messagesCli:      messagesCli,
+		filesCli:         filesCli,
+		createProfiles:   settings.CreateProfilesOnRead,
+	}, nil
+}
+
+// Read resolves everything linked to the given firebase user.
+//
+// Missing consumer and producer records are created when the service is
+// configured to do so, otherwise they are left out of the profile.
+func (s *MeService) Read(user *Middleware.FirebaseUser) (*Profile, error) {
+	profile := &Profile{
+		User:  *user,
+		Roles: []string{},
+	}
+
+	consumer, err := s.consumersCli.FindFromUserId(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if consumer == nil && s.createProfiles {
+		consumer, err = s.consumersCli.CreateOrGet(&Consumers.Consumer{UserId: user.UserId})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	producer, err := s.producersCli.FindFromUserId(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if producer == nil && s.createProfiles {
+		producer, err = s.producersCli.CreateOrGet(&Producers.Producer{UserId: user.UserId})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if consumer != nil {
+		profile.Consumer = consumer
+		profile.Roles = append(profile.Roles, ConsumerRole)
+
+		orders, err := s.ordersCli.ListForConsumer(consumer.SK)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			if order.Completed == "" && !order.IsDeleted {
+				profile.OpenOrders++
+			}
+		}
+
+		subscriptions, err := s.subscriptionsCli.ListForConsumer(consumer.SK)
+		if err != nil {
+			return nil, err
+		}
+		for _, subscription := range subscriptions {
+			if !subscription.Cancelled && !subscription.IsDeleted {
+				profile.ActiveSubscriptions++
+			}
+		}
+	}
+
+	if producer != nil {
+		profile.Producer = producer
+		profile.Roles = append(profile.Roles, ProducerRole)
+	}
+
+	return profile, nil
+}
+
+// Export collects every record linked to the user and bundles it, together
+// with the user's uploaded images, into a zip archive.
+func (s *MeService) Export(user *Middleware.FirebaseUser) ([]byte, error) {
+	export := &Export{User: *user}
+
+	consumer, err := s.consumersCli.FindFromUserId(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if consumer != nil {
+		export.Consumer = consumer
+		export.Orders, err = s.ordersCli.ListForConsumer(consumer.SK)
+		if err != nil {
+			return nil, err
+		}
+		export.Subscriptions, err = s.subscriptionsCli.ListForConsumer(consumer.SK)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	producer, err := s.producersCli.FindFromUserId(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if producer != nil {
+		export.Producer = producer
+		export.Services, err = s.producersCli.GetServices(producer.SK)
+		if err != nil {
+			return nil, err
+		}
+		export.Items, err = s.producersCli.GetOwnedItems(producer.SK)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	export.Messages, err = s.messagesCli.ListFromSender(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.filesCli.ListUserImages(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, key := range keys {
+		data, err := s.filesCli.ReadImage(key)
+		if err != nil {
+			return nil, err
+		}
+		name := "files/" + path.Base(key)
+		f, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.Write(data)
+		if err != nil {
+			return nil, err
+		}
+		export.Files = append(export.Files, name)
+	}
+
+	f, err := archive.Create("data.json")
+	if err != nil {
+		return nil, err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(export)
+	if err != nil {
+		return nil, err
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Erase anonymizes the personal details copied into the user's orders,
+// subscriptions and messages, tombstones every record linked to the user and deletes
+// their uploaded images.
+func (s *MeService) Erase(ctx context.Context, user *Middleware.FirebaseUser) (*ErasureResult, error) {
+	result := &ErasureResult{}
+
+	consumer, err := s.consumersCli.FindFromUserId(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if consumer != nil {
+		orders, err := s.ordersCli.ListForConsumer(consumer.SK)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			_, err = s.ordersCli.Erase(ctx, order)
+			if err != nil {
+				return nil, err
+			}
+			result.Orders++
+		}
+
+		subscriptions, err := s.subscriptionsCli.ListForConsumer(consumer.SK)
+		if err != nil {
+			return nil, err
+		}
+		for _, subscription := range subscriptions {
+			_, err = s.subscriptionsCli.Erase(subscription)
+			if err != nil {
+				return nil, err
+			}
+			result.Subscriptions++
+		}
+
+		_, err = s.consumersCli.Erase(consumer)
+		if err != nil {
+			return nil, err
+		}
+		result.Consumer = true
+	}
+
+	producer, err := s.producersCli.FindFromUserId(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if producer != nil {
+		_, err = s.producersCli.Erase(producer)
+		if err != nil {
+			return nil, err
+		}
+		result.Producer = true
+	}
+
+	messages, err := s.messagesCli.ListFromSender(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	for _, message := range messages {
+		_, err = s.messagesCli.Erase(message)
+		if err != nil {
+			return nil, err
+		}
+		result.Messages++
+	}
+
+	keys, err := s.filesCli.ListUserImages(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := s.filesCli.DeleteImages(keys)
+	result.Files = len(deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
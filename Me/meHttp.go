@@ -0,0 +1,117 @@
+package Me
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Flags"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"log"
+	"net/http"
+)
+
+type MeHttpService struct {
+	service *MeService
+	flags   *Flags.FlagService
+}
+
+// FlagsOutput lists the feature flags on for the user.
+type FlagsOutput struct {
+	Flags []string `json:"flags"`
+}
+
+func NewMeHttpService(settings *Settings.Settings) (*MeHttpService, error) {
+	service, err := NewMeService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MeHttpService{
+		service: service,
+		flags:   settings.Flags,
+	}, nil
+}
+
+func (s *MeHttpService) Read(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	profile, err := s.service.Read(user)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	outData, err := json.Marshal(profile)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	_, err = fmt.Fprint(w, string(outData))
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+	}
+}
+
+func (s *MeHttpService) Export(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	archive, err := s.service.Export(user)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "export-"+user.UserId+".zip"))
+	_, err = w.Write(archive)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+	}
+}
+
+func (s *MeHttpService) Erase(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	result, err := s.service.Erase(r.Context(), user)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	outData, err := json.Marshal(result)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	_, err = fmt.Fprint(w, string(outData))
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+	}
+}
+
+// Flags tells the app which flows to show the user.
+func (s *MeHttpService) Flags(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	Middleware.WriteJson(w, r, http.StatusOK, &FlagsOutput{
+		Flags: s.flags.Evaluate(r.Context(), user),
+	})
+}
+
+func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
+	server, err := NewMeHttpService(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+	router := r.PathPrefix("/me").Subrouter()
+
+	router.Use(settings.MiddlewareService.ValidateToken)
+
+	router.HandleFunc("", server.Read).Methods("GET", "OPTIONS")
+	router.HandleFunc("", server.Erase).Methods("DELETE")
+	router.HandleFunc("/export", server.Export).Methods("GET", "OPTIONS")
+	router.HandleFunc("/flags", server.Flags).Methods("GET", "OPTIONS")
+}
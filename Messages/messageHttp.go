@@ -0,0 +1,162 @@
+package Messages
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"log"
+	"net/http"
+)
+
+type MessageHttpService struct {
+	service *MessageService
+}
+
+func NewMessageHttpService(settings *Settings.Settings) (*MessageHttpService, error) {
+	service, err := NewMessageService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessageHttpService{
+		service: service,
+	}, nil
+}
+
+func (s *MessageHttpService) OpenOrderThread(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+	orderId := mux.Vars(r)["orderId"]
+
+	thread, err := s.service.OpenOrderThread(user, orderId)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	Middleware.WriteJson(w, r, http.StatusOK, thread)
+}
+
+func (s *MessageHttpService) OpenSubscriptionThread(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+	subscriptionId := mux.Vars(r)["subscriptionId"]
+
+	thread, err := s.service.OpenSubscriptionThread(user, subscriptionId)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	Middleware.WriteJson(w, r, http.StatusOK, thread)
+}
+
+func (s *MessageHttpService) List(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+
+	threads, err := s.service.List(user)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	Middleware.WriteJson(w, r, http.StatusOK, threads)
+}
+
+func (s *MessageHttpService) Read(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+	threadId := mux.Vars(r)["threadId"]
+
+	thread, err := s.service.Read(user, threadId)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	if Middleware.NotModified(w, r, Utils.ETagOf(thread)) {
+		return
+	}
+
+	Middleware.WriteJson(w, r, http.StatusOK, thread)
+}
+
+func (s *MessageHttpService) ListMessages(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+	threadId := mux.Vars(r)["threadId"]
+
+	thread, err := s.service.Read(user, threadId)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	messages, err := s.service.ListMessages(thread)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	Middleware.WriteJson(w, r, http.StatusOK, messages)
+}
+
+func (s *MessageHttpService) Send(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+	threadId := mux.Vars(r)["threadId"]
+
+	var data Message
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	thread, err := s.service.Read(user, threadId)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	message, err := s.service.Send(user, thread, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	Middleware.WriteJson(w, r, http.StatusCreated, message)
+}
+
+func (s *MessageHttpService) MarkRead(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+	threadId := mux.Vars(r)["threadId"]
+
+	thread, err := s.service.Read(user, threadId)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	thread, err = s.service.MarkRead(user, thread)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	Middleware.WriteJson(w, r, http.StatusOK, thread)
+}
+
+func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
+	server, err := NewMessageHttpService(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+	router := r.PathPrefix("/thread").Subrouter()
+
+	router.Use(settings.MiddlewareService.ValidateToken)
+
+	router.HandleFunc("/list", server.List).Methods("GET", "OPTIONS")
+	router.HandleFunc("/order/{orderId}", server.OpenOrderThread).Methods("POST", "OPTIONS")
+	router.HandleFunc("/subscription/{subscriptionId}", server.OpenSubscriptionThread).Methods("POST", "OPTIONS")
+	router.HandleFunc("/{threadId}/messages", server.ListMessages).Methods("GET", "OPTIONS")
+	router.HandleFunc("/{threadId}/messages", server.Send).Methods("POST")
+	router.HandleFunc("/{threadId}/read", server.MarkRead).Methods("POST", "OPTIONS")
+	router.HandleFunc("/{threadId}", server.Read).Methods("GET", "OPTIONS")
+}
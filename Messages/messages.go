@@ -0,0 +1,568 @@
+package Messages
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jonathanpatta/apartmentservices/Consumers"
+	"github.com/jonathanpatta/apartmentservices/Files"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Orders"
+	"github.com/jonathanpatta/apartmentservices/Producers"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Subscriptions"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ThreadPrefix  = "THREAD#"
+	MessagePrefix = "MESSAGE#"
+	// ParticipantPrefix and SenderPrefix, followed by a user id, are the PK
+	// of the pointers to the threads the user takes part in and to the
+	// messages they sent. Each pointer has the SK of the record it points to.
+	ParticipantPrefix = "PARTICIPANT#"
+	SenderPrefix      = "SENDER#"
+)
+
+// pointer is a record whose SK is that of a thread or message, kept under a
+// user's own partition so that what the user takes part in can be queried
+// by key.
+type pointer struct {
+	PK string
+	SK string
+}
+
+// Thread is the conversation between the consumer who placed an order or
+// subscription and the producer of its item. Its SK is the SK of the order
+// or subscription followed by ThreadPrefix, so each has at most one thread.
+type Thread struct {
+	Utils.Meta
+	SubjectId      string `json:"subject_id,omitempty"`
+	ConsumerId     string `json:"consumer_id,omitempty"`
+	ProducerId     string `json:"producer_id,omitempty"`
+	ConsumerUserId string `json:"consumer_user_id,omitempty"`
+	ProducerUserId string `json:"producer_user_id,omitempty"`
+	// LastMessageAt and ReadAt are in unix milliseconds. ReadAt maps the user
+	// id of each participant to when they last read the thread.
+	LastMessageAt int64            `json:"last_message_at,omitempty"`
+	ReadAt        map[string]int64 `json:"read_at,omitempty"`
+}
+
+// IsParticipant reports whether the user is one of the two sides of the
+// thread.
+func (t *Thread) IsParticipant(userId string) bool {
+	return userId != "" && (userId == t.ConsumerUserId || userId == t.ProducerUserId)
+}
+
+// otherParticipant is the user id of the participant that is not userId.
+func (t *Thread) otherParticipant(userId string) string {
+	if userId == t.ConsumerUserId {
+		return t.ProducerUserId
+	}
+	return t.ConsumerUserId
+}
+
+type Message struct {
+	Utils.Meta
+	Body     string `json:"body,omitempty" validate:"max=2000"`
+	ImageUrl string `json:"image_url,omitempty" validate:"max=1024"`
+
+	SenderUserId string `json:"sender_user_id,omitempty"`
+	SenderName   string `json:"sender_name,omitempty"`
+	// SentAt orders messages sent within the same second, in unix
+	// milliseconds.
+	SentAt int64 `json:"sent_at,omitempty"`
+	// Read is whether the other participant has read the message, derived
+	// from the thread's ReadAt when listing.
+	Read bool `json:"read" dynamodbav:"-"`
+}
+
+type MessageService struct {
+	db               *dynamodb.Client
+	dynamodbSettings *Settings.DynamoDbSettings
+	ordersCli        *Orders.OrderService
+	subscriptionsCli *Subscriptions.SubscriptionService
+	consumersCli     *Consumers.ConsumerService
+	producersCli     *Producers.ProducerService
+	filesCli         *Files.S3FileService
+}
+
+func NewMessageService(settings *Settings.Settings) (*MessageService, error) {
+	ordersCli, err := Orders.NewOrderService(settings)
+	if err != nil {
+		return nil, err
+	}
+	subscriptionsCli, err := Subscriptions.NewSubscriptionService(settings)
+	if err != nil {
+		return nil, err
+	}
+	consumersCli, err := Consumers.NewConsumerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	producersCli, err := Producers.NewProducerService(settings)
+	if err != nil {
+		return nil, err
+	}
+	filesCli, err := Files.NewS3FileService(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessageService{
+		db:               settings.Dynamo.Cli,
+		dynamodbSettings: settings.Dynamo,
+		ordersCli:        ordersCli,
+		subscriptionsCli: subscriptionsCli,
+		consumersCli:     consumersCli,
+		producersCli:     producersCli,
+		filesCli:         filesCli,
+	}, nil
+}
+
+func nowMillis() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// firstSegment is the SK of the top level record an SK was created under.
+func firstSegment(sk string) string {
+	i := strings.Index(sk, "_")
+	if i < 0 {
+		return ""
+	}
+	return sk[:i]
+}
+
+// OpenOrderThread returns the thread about an order, creating it on first
+// use. Only the consumer who placed the order and the producer of its item
+// can open it.
+func (s *MessageService) OpenOrderThread(user *Middleware.FirebaseUser, orderId string) (*Thread, error) {
+	order, err := s.ordersCli.Read(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.IsDeleted {
+		return nil, Utils.NewError(Utils.NotFound, "order %v not found", orderId)
+	}
+	return s.openThread(user, order.SK, order.ItemId)
+}
+
+// OpenSubscriptionThread is OpenOrderThread for subscriptions.
+func (s *MessageService) OpenSubscriptionThread(user *Middleware.FirebaseUser, subscriptionId string) (*Thread, error) {
+	subscription, err := s.subscriptionsCli.Read(subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.IsDeleted {
+		return nil, Utils.NewError(Utils.NotFound, "subscription %v not found", subscriptionId)
+	}
+	return s.openThread(user, subscription.SK, subscription.ItemId)
+}
+
+func (s *MessageService) openThread(user *Middleware.FirebaseUser, subjectId string, itemId string) (*Thread, error) {
+	thread, err := s.threadFor(subjectId)
+	if err != nil {
+		return nil, err
+	}
+	if thread != nil {
+		if !thread.IsParticipant(user.UserId) {
+			return nil, notParticipant()
+		}
+		return thread, nil
+	}
+
+	consumer, err := s.consumersCli.Read(firstSegment(subjectId))
+	if err != nil {
+		return nil, err
+	}
+	producer, err := s.producersCli.Read(firstSegment(itemId))
+	if err != nil {
+		return nil, err
+	}
+
+	thread = &Thread{
+		SubjectId:      subjectId,
+		ConsumerId:     consumer.SK,
+		ProducerId:     producer.SK,
+		ConsumerUserId: consumer.UserId,
+		ProducerUserId: producer.UserId,
+		ReadAt:         map[string]int64{},
+	}
+	if !thread.IsParticipant(user.UserId) {
+		return nil, notParticipant()
+	}
+	for _, userId := range []string{consumer.UserId, producer.UserId} {
+		if userId != "" {
+			thread.ReadAt[userId] = 0
+		}
+	}
+
+	thread.PK = ThreadPrefix
+	thread.SK = threadId(subjectId)
+	thread.SetCreatedAtNow()
+	thread.SetLastModifiedNow()
+	data, err := attributevalue.MarshalMap(thread)
+	if err != nil {
+		return nil, err
+	}
+	writes := []types.TransactWriteItem{{Put: &types.Put{
+		Item:                data,
+		TableName:           s.dynamodbSettings.TableName,
+		ConditionExpression: aws.String("attribute_not_exists(SK)"),
+	}}}
+	for userId := range thread.ReadAt {
+		write, err := s.putPointer(ParticipantPrefix+userId, thread.SK)
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, write)
+	}
+
+	_, err = s.db.TransactWriteItems(context.Background(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: writes,
+	})
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) && len(canceled.CancellationReasons) > 0 &&
+		aws.ToString(canceled.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+		// The other participant opened the thread at the same time.
+		thread, err = s.threadFor(subjectId)
+		if err != nil {
+			return nil, err
+		}
+		if thread == nil {
+			return nil, Utils.NewError(Utils.Conflict, "thread %v is being opened, try again", threadId(subjectId))
+		}
+		if !thread.IsParticipant(user.UserId) {
+			return nil, notParticipant()
+		}
+		return thread, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// putPointer writes a pointer to the record with the given SK.
+func (s *MessageService) putPointer(pk string, sk string) (types.TransactWriteItem, error) {
+	data, err := attributevalue.MarshalMap(&pointer{PK: pk, SK: sk})
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+	return types.TransactWriteItem{Put: &types.Put{
+		Item:      data,
+		TableName: s.dynamodbSettings.TableName,
+	}}, nil
+}
+
+// pointedTo returns the SKs of the pointers under pk.
+func (s *MessageService) pointedTo(pk string) ([]string, error) {
+	keyFilter := expression.Key("PK").Equal(expression.Value(pk))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(s.db, &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+	})
+
+	var sks []string
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		var page []*pointer
+		err = attributevalue.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page {
+			sks = append(sks, p.SK)
+		}
+	}
+	return sks, nil
+}
+
+// threadId is the SK of the thread about an order or subscription.
+func threadId(subjectId string) string {
+	return subjectId + "_" + ThreadPrefix
+}
+
+func notParticipant() error {
+	return Utils.NewError(Utils.Forbidden, "only the consumer and the producer can take part in this thread")
+}
+
+// threadFor returns the thread about an order or subscription, or nil when
+// there is none yet.
+func (s *MessageService) threadFor(subjectId string) (*Thread, error) {
+	return s.readThread(threadId(subjectId))
+}
+
+// readThread returns the thread with the given SK, or nil when there is
+// none.
+func (s *MessageService) readThread(threadId string) (*Thread, error) {
+	out, err := s.db.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName: s.dynamodbSettings.TableName,
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: ThreadPrefix},
+			"SK": &types.AttributeValueMemberS{Value: threadId},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil || out.Item == nil {
+		return nil, err
+	}
+
+	var thread Thread
+	err = attributevalue.UnmarshalMap(out.Item, &thread)
+	if err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// Read returns a thread the user takes part in.
+func (s *MessageService) Read(user *Middleware.FirebaseUser, threadId string) (*Thread, error) {
+	thread, err := s.readThread(threadId)
+	if err != nil {
+		return nil, err
+	}
+	if thread == nil {
+		return nil, Utils.NewError(Utils.NotFound, "thread %v not found", threadId)
+	}
+	if !thread.IsParticipant(user.UserId) {
+		return nil, notParticipant()
+	}
+	return thread, nil
+}
+
+// List returns the threads the user takes part in, the most recently active
+// first.
+func (s *MessageService) List(user *Middleware.FirebaseUser) ([]*Thread, error) {
+	threads := []*Thread{}
+	if user.UserId == "" {
+		return threads, nil
+	}
+	threadIds, err := s.pointedTo(ParticipantPrefix + user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.dynamodbSettings.BatchGet(ThreadPrefix, threadIds)
+	if err != nil {
+		return nil, err
+	}
+	err = attributevalue.UnmarshalListOfMaps(records, &threads)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(threads, func(i, j int) bool {
+		return threads[i].LastMessageAt > threads[j].LastMessageAt
+	})
+	return threads, nil
+}
+
+// Send adds a message from the user to the thread, which also marks the
+// thread read by the sender. An image must have been uploaded by the sender
+// through the Files service.
+func (s *MessageService) Send(user *Middleware.FirebaseUser, thread *Thread, in *Message) (*Message, error) {
+	if in.Body == "" && in.ImageUrl == "" {
+		return nil, &Utils.Error{
+			Code:    Utils.Validation,
+			Message: "invalid input",
+			Fields:  map[string]string{"body": "required without image_url"},
+		}
+	}
+	if in.ImageUrl != "" && !s.filesCli.IsUserImageUrl(user.UserId, in.ImageUrl) {
+		return nil, &Utils.Error{
+			Code:    Utils.Validation,
+			Message: "invalid input",
+			Fields:  map[string]string{"image_url": "must be an image uploaded by the sender"},
+		}
+	}
+
+	message := &Message{
+		Body:         in.Body,
+		ImageUrl:     in.ImageUrl,
+		SenderUserId: user.UserId,
+		SenderName:   user.Name,
+		SentAt:       nowMillis(),
+	}
+	err := message.New(MessagePrefix, thread.SK)
+	if err != nil {
+		return nil, err
+	}
+	data, err := attributevalue.MarshalMap(message)
+	if err != nil {
+		return nil, err
+	}
+	sent, err := s.putPointer(SenderPrefix+user.UserId, message.SK)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.db.TransactWriteItems(context.Background(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{Item: data, TableName: s.dynamodbSettings.TableName}},
+			sent,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	update := expression.Set(expression.Name("LastMessageAt"), expression.Value(message.SentAt)).
+		Set(expression.Name("ReadAt."+user.UserId), expression.Value(message.SentAt))
+	err = s.updateThread(thread, update)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// MarkRead records that the user has read every message of the thread so
+// far.
+func (s *MessageService) MarkRead(user *Middleware.FirebaseUser, thread *Thread) (*Thread, error) {
+	now := nowMillis()
+	err := s.updateThread(thread, expression.Set(expression.Name("ReadAt."+user.UserId), expression.Value(now)))
+	if err != nil {
+		return nil, err
+	}
+	thread.ReadAt[user.UserId] = now
+	return thread, nil
+}
+
+// updateThread sets single attributes of the thread, so that the two
+// participants writing at once do not overwrite each other's read receipts.
+func (s *MessageService) updateThread(thread *Thread, update expression.UpdateBuilder) error {
+	thread.SetLastModifiedNow()
+	update = update.Set(expression.Name("LastModified"), expression.Value(thread.LastModified)).
+		Add(expression.Name("Version"), expression.Value(1))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	if err != nil {
+		return err
+	}
+	_, err = s.db.UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+		TableName: s.dynamodbSettings.TableName,
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: thread.PK},
+			"SK": &types.AttributeValueMemberS{Value: thread.SK},
+		},
+		UpdateExpression:          expr.Update(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	return err
+}
+
+// ListMessages returns the messages of a thread, oldest first, each marked
+// read when the participant who did not send it has read the thread since.
+func (s *MessageService) ListMessages(thread *Thread) ([]*Message, error) {
+	keyFilter := expression.Key("PK").Equal(expression.Value(MessagePrefix)).
+		And(expression.Key("SK").BeginsWith(thread.SK + "_"))
+	filter := expression.Name("IsDeleted").NotEqual(expression.Value(true))
+
+	messages, err := s.queryMessages(keyFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].SentAt < messages[j].SentAt
+	})
+	for _, message := range messages {
+		message.Read = thread.ReadAt[thread.otherParticipant(message.SenderUserId)] >= message.SentAt
+	}
+	return messages, nil
+}
+
+// ListFromSender returns every message the user has sent, oldest first.
+func (s *MessageService) ListFromSender(userId string) ([]*Message, error) {
+	messages := []*Message{}
+	if userId == "" {
+		return messages, nil
+	}
+	messageIds, err := s.pointedTo(SenderPrefix + userId)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.dynamodbSettings.BatchGet(MessagePrefix, messageIds)
+	if err != nil {
+		return nil, err
+	}
+	err = attributevalue.UnmarshalListOfMaps(records, &messages)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].SentAt < messages[j].SentAt
+	})
+	return messages, nil
+}
+
+func (s *MessageService) queryMessages(keyFilter expression.KeyConditionBuilder, filter expression.ConditionBuilder) ([]*Message, error) {
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(s.db, &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+	})
+
+	var data []*Message
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		var page []*Message
+		err = attributevalue.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, page...)
+	}
+	return data, nil
+}
+
+// Erase strips the body, image and sender name from the message and
+// tombstones it.
+func (s *MessageService) Erase(in *Message) (*Message, error) {
+	in.Body = ""
+	in.ImageUrl = ""
+	in.SenderName = ""
+	in.IsDeleted = true
+	in.SetLastModifiedNow()
+
+	data, err := attributevalue.MarshalMap(in)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      data,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return in, nil
+}
@@ -0,0 +1,85 @@
+package Metrics
+
+import (
+	"context"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Requests served, by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to serve a request, by method and route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	storageCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_calls_total",
+		Help: "Calls made to AWS, by service, operation and outcome.",
+	}, []string{"service", "operation", "outcome"})
+
+	storageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "storage_call_duration_seconds",
+		Help:    "Time taken by calls to AWS, by service and operation.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"service", "operation"})
+
+	OrdersCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "orders_created_total",
+		Help: "Orders placed by consumers.",
+	})
+
+	SubscriptionsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "subscriptions_created_total",
+		Help: "Subscriptions started by consumers.",
+	})
+
+	ImagesUploaded = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "images_uploaded_total",
+		Help: "Images stored in the files bucket.",
+	})
+)
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRequest records one served request.
+func ObserveRequest(method string, route string, status int, duration time.Duration) {
+	requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	requestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// InstrumentAWS adds a middleware to every client built from the options,
+// timing each call such as Query, PutItem or PutObject.
+func InstrumentAWS(apiOptions *[]func(*middleware.Stack) error) {
+	*apiOptions = append(*apiOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("Metrics",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				out, metadata, err := next.HandleInitialize(ctx, in)
+
+				service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+				outcome := "ok"
+				if err != nil {
+					outcome = "error"
+				}
+				storageCalls.WithLabelValues(service, operation, outcome).Inc()
+				storageDuration.WithLabelValues(service, operation).Observe(time.Since(start).Seconds())
+
+				return out, metadata, err
+			}), middleware.Before)
+	})
+}
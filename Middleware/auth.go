@@ -4,20 +4,43 @@ import (
 	"context"
 	"errors"
 	"firebase.google.com/go/v4/auth"
-	"fmt"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Utils"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type MiddlwareService struct {
-	auth *auth.Client
+	auth        *auth.Client
+	suspensions *suspensionCache
 }
 
+// SuspensionChecker reports whether a user has been suspended by an admin.
+type SuspensionChecker interface {
+	IsSuspended(userId string) (bool, error)
+}
+
+const AdminRole = "admin"
+
 type FirebaseUser struct {
 	Name    string
 	Email   string
 	Picture string
 	UserId  string
+	Roles   []string
+	// Community is the building the user lives in, from the community
+	// custom claim.
+	Community string
+}
+
+func (u *FirebaseUser) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
 }
 
 func GetFirebaseUser(ctx context.Context) *FirebaseUser {
@@ -44,6 +67,19 @@ func GetFirebaseUserFromToken(token *auth.Token) FirebaseUser {
 		user.Picture = picture.(string)
 	}
 
+	roles := token.Claims["roles"]
+	if rolesInterface, ok := roles.([]interface{}); ok {
+		for _, role := range rolesInterface {
+			if str, ok := role.(string); ok {
+				user.Roles = append(user.Roles, str)
+			}
+		}
+	}
+
+	if community, ok := token.Claims["community"].(string); ok {
+		user.Community = community
+	}
+
 	email := token.Firebase.Identities["email"]
 	if email != nil {
 		emailsInterface := email.([]interface{})
@@ -65,36 +101,81 @@ func NewMiddlwareService(auth *auth.Client) (*MiddlwareService, error) {
 	}, nil
 }
 
+// SetSuspensionChecker makes VerifyToken reject requests from suspended
+// users, keeping each answer of the checker for ttl.
+func (s *MiddlwareService) SetSuspensionChecker(checker SuspensionChecker, ttl time.Duration) {
+	s.suspensions = newSuspensionCache(checker, ttl)
+}
+
+// ForgetSuspension drops the remembered answer for the user, for when they
+// have just been suspended or reinstated.
+func (s *MiddlwareService) ForgetSuspension(userId string) {
+	if s.suspensions != nil {
+		s.suspensions.forget(userId)
+	}
+}
+
 const TokenName = "Authorization"
 
 func AuthError(w http.ResponseWriter, r *http.Request, err error) {
-	errStr := fmt.Sprintf("Auth Error:%v", err)
-	http.Error(w, errStr, http.StatusUnauthorized)
+	WriteError(w, r, Utils.NewError(Utils.Unauthorized, "Auth Error:%v", err))
+}
+
+// VerifyToken checks a bearer token and returns a context carrying its user,
+// as read back by GetFirebaseUser. Suspended users are rejected.
+func (s *MiddlwareService) VerifyToken(ctx context.Context, val string) (context.Context, error) {
+	val = strings.ReplaceAll(val, "Bearer ", "")
+	if val == "" {
+		return nil, Utils.NewError(Utils.Unauthorized, "Auth Error:%v", errors.New("invalid token"))
+	}
+	token, err := s.auth.VerifyIDToken(context.Background(), val)
+	if err != nil {
+		return nil, Utils.NewError(Utils.Unauthorized, "Auth Error:%v", err)
+	}
+
+	user := GetFirebaseUserFromToken(token)
+
+	if s.suspensions != nil && !user.HasRole(AdminRole) && s.suspensions.IsSuspended(ctx, user.UserId) {
+		return nil, Utils.NewError(Utils.Forbidden, "account suspended")
+	}
+
+	// Set puts the user on the access log of the request, the returned ctx
+	// carries it too for callers logging through Default.
+	logger := Logger.FromContext(ctx)
+	logger.Set("user_id", user.UserId)
+	ctx = Logger.NewContext(ctx, logger.With("user_id", user.UserId))
+
+	return context.WithValue(ctx, "user", user), nil
 }
 
 func (s *MiddlwareService) ValidateToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		val := r.Header.Get(TokenName)
-		val = strings.ReplaceAll(val, "Bearer ", "")
-		if val == "" {
-			AuthError(w, r, errors.New("invalid token"))
-			return
-		}
-		token, err := s.auth.VerifyIDToken(context.Background(), val)
+		ctx, err := s.VerifyToken(r.Context(), r.Header.Get(TokenName))
 		if err != nil {
-			AuthError(w, r, err)
+			WriteError(w, r, err)
 			return
 		}
-
-		user := GetFirebaseUserFromToken(token)
-
-		ctx := context.WithValue(r.Context(), "user", user)
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// RequireRole only lets through users holding the role. It must run after
+// ValidateToken.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := GetFirebaseUser(r.Context())
+			if !user.HasRole(role) {
+				WriteError(w, r, Utils.NewError(Utils.Forbidden, "missing role %v", role))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (s *MiddlwareService) ValidateTokenHandler(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		val := r.Header.Get(TokenName)
@@ -0,0 +1,226 @@
+package Middleware
+
+import (
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressMinSize is the smallest response worth compressing, smaller ones
+// grow more from the encoding headers than they shrink.
+const compressMinSize = 1024
+
+var encoders = map[string]*sync.Pool{
+	"br": {New: func() interface{} {
+		return brotli.NewWriterLevel(nil, brotli.DefaultCompression)
+	}},
+	"gzip": {New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}},
+}
+
+// encoder is implemented by both brotli and gzip writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// negotiateEncoding picks br or gzip from an Accept-Encoding header,
+// preferring br when both are equally acceptable. It returns "" when neither
+// is.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, q := parseQuality(part)
+		if name == "*" {
+			name = "br"
+		}
+		if _, ok := encoders[name]; !ok || q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "br") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+func parseQuality(part string) (string, float64) {
+	fields := strings.Split(part, ";")
+	name := strings.ToLower(strings.TrimSpace(fields[0]))
+	q := 1.0
+	for _, param := range fields[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+				q = value
+			}
+		}
+	}
+	return name, q
+}
+
+// incompressible reports whether a content type is already compressed.
+func incompressible(contentType string) bool {
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/zip", "application/gzip", "application/x-gzip"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter holds back the start of a response until it knows whether
+// the response is large enough to compress, then writes the rest through the
+// encoder as it comes.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	// Responses without a body are passed on at once.
+	if status == http.StatusNoContent || status == http.StatusNotModified || status < 200 {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < compressMinSize {
+			return len(b), nil
+		}
+		buf := w.buf
+		w.buf = nil
+		if err := w.start(buf); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// start decides on the encoding once the response has grown past
+// compressMinSize and writes what was held back.
+func (w *compressWriter) start(buf []byte) error {
+	header := w.Header()
+	if header.Get("Content-Type") == "" {
+		// Sniff before compressing, net/http would otherwise sniff the
+		// encoded bytes.
+		header.Set("Content-Type", http.DetectContentType(buf))
+	}
+	w.decide(header.Get("Content-Encoding") == "" && !incompressible(header.Get("Content-Type")))
+
+	if w.encoder != nil {
+		_, err := w.encoder.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) decide(compress bool) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	if compress {
+		w.Header().Set("Content-Encoding", w.encoding)
+		w.Header().Del("Content-Length")
+		if etag := w.Header().Get("ETag"); etag != "" {
+			w.Header().Set("ETag", encodedETag(etag, w.encoding))
+		}
+		w.encoder = encoders[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// Flush sends what has been written so far, compressing it when the
+// response has not yet been found too small to compress. Streams that flush
+// early are expected to keep going.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		buf := w.buf
+		w.buf = nil
+		if len(buf) > 0 {
+			w.start(buf)
+		} else {
+			w.decide(w.Header().Get("Content-Encoding") == "" && !incompressible(w.Header().Get("Content-Type")))
+		}
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// close writes out a response that stayed under compressMinSize and ends
+// the encoded stream of one that did not.
+func (w *compressWriter) close() error {
+	if !w.decided {
+		w.decide(false)
+		if len(w.buf) > 0 {
+			_, err := w.ResponseWriter.Write(w.buf)
+			return err
+		}
+		return nil
+	}
+	if w.encoder == nil {
+		return nil
+	}
+	err := w.encoder.Close()
+	w.encoder.Reset(nil)
+	encoders[w.encoding].Put(w.encoder)
+	w.encoder = nil
+	return err
+}
+
+// CompressionMiddleware compresses responses with br or gzip, as negotiated
+// with the client's Accept-Encoding, once they pass compressMinSize. The
+// ETag of a compressed response gets the encoding as a suffix, such as
+// "…-gzip", since its bytes differ from the other encodings. NotModified and
+// CheckIfMatch ignore the suffix, so the tag still names the record version.
+//
+// It is installed by the http server in main.go only, not by the router the
+// lambda serves.
+func CompressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: w, encoding: encoding}
+		next.ServeHTTP(writer, r)
+		writer.close()
+	})
+}
@@ -0,0 +1,61 @@
+package Middleware
+
+import (
+	"encoding/json"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"net/http"
+)
+
+type ErrorResponse struct {
+	Code      Utils.ErrorCode   `json:"code"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	RequestId string            `json:"request_id,omitempty"`
+}
+
+var errorStatus = map[Utils.ErrorCode]int{
+	Utils.BadRequest:   http.StatusBadRequest,
+	Utils.Validation:   http.StatusBadRequest,
+	Utils.Unauthorized: http.StatusUnauthorized,
+	Utils.Forbidden:    http.StatusForbidden,
+	Utils.NotFound:     http.StatusNotFound,
+	Utils.Conflict:     http.StatusConflict,
+	Utils.Internal:     http.StatusInternalServerError,
+
+	Utils.PreconditionFailed: http.StatusPreconditionFailed,
+	Utils.Unprocessable:      http.StatusUnprocessableEntity,
+}
+
+// NewErrorResponse builds the json error envelope and status for err.
+//
+// Errors that are not a Utils.Error are treated as internal and their message
+// is only logged.
+func NewErrorResponse(r *http.Request, err error) (int, ErrorResponse) {
+	code := Utils.ErrorCodeOf(err)
+	status, ok := errorStatus[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	resp := ErrorResponse{
+		Code:      code,
+		Message:   err.Error(),
+		Fields:    Utils.ErrorFieldsOf(err),
+		RequestId: GetRequestId(r.Context()),
+	}
+	if status == http.StatusInternalServerError {
+		Logger.FromContext(r.Context()).Error("internal error", "error", err)
+		resp.Message = http.StatusText(status)
+	}
+	return status, resp
+}
+
+// WriteError reports err to the client as a json error envelope.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	status, resp := NewErrorResponse(r, err)
+
+	w.Header().Set("content-type", "application/json;charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,82 @@
+package Middleware
+
+import (
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"net/http"
+	"strings"
+)
+
+// NotModified sets the ETag header and, when the request's If-None-Match
+// already names it, answers 304 and reports true.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	w.Header().Set("ETag", etag)
+
+	if !matchesAny(r.Header.Get("If-None-Match"), etag, true) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// CheckIfMatch rejects a write whose If-Match header does not name the
+// current ETag of the record. current is only called when the header is set.
+// It returns the Version the write must still find when it is stored, or nil
+// without the header, so that a write landing in between is rejected too.
+func CheckIfMatch(r *http.Request, current func() (*Utils.Meta, error)) (*int64, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil, nil
+	}
+	meta, err := current()
+	if err != nil {
+		return nil, err
+	}
+	if !matchesAny(header, meta.ETag(), false) {
+		return nil, Utils.NewError(Utils.PreconditionFailed, "record has changed since %v", header)
+	}
+	version := meta.Version
+	return &version, nil
+}
+
+// matchesAny reports whether a list of entity tags names etag. Weak tags
+// only match when weak comparison is allowed, as for If-None-Match.
+func matchesAny(header string, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+		if decodedETag(tag) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// encodedETag suffixes an entity tag with the content encoding of the
+// response it is sent with.
+func encodedETag(etag string, encoding string) string {
+	if !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+}
+
+// decodedETag removes the suffix encodedETag added.
+func decodedETag(etag string) string {
+	for encoding := range encoders {
+		if suffix := "-" + encoding + `"`; strings.HasSuffix(etag, suffix) {
+			return strings.TrimSuffix(etag, suffix) + `"`
+		}
+	}
+	return etag
+}
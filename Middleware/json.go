@@ -0,0 +1,74 @@
+package Middleware
+
+import (
+	"encoding/json"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"net/http"
+	"reflect"
+)
+
+// WriteJson encodes data straight onto the response instead of marshalling
+// it into memory first. Slices are written one element at a time, so a long
+// list is never held encoded in full, and compressed as it goes when
+// CompressionMiddleware is in front.
+//
+// Once the first element is written the status can no longer change, so
+// encoding errors past that point are only logged.
+func WriteJson(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	value := reflect.ValueOf(data)
+	if value.Kind() == reflect.Ptr && value.Elem().Kind() == reflect.Slice {
+		value = value.Elem()
+	}
+	if !streamable(value) {
+		outData, err := json.Marshal(data)
+		if err != nil {
+			WriteError(w, r, err)
+			return
+		}
+		w.WriteHeader(status)
+		_, err = w.Write(outData)
+		if err != nil {
+			Logger.FromContext(r.Context()).Error("could not write response", "error", err)
+		}
+		return
+	}
+
+	w.WriteHeader(status)
+	err := writeJsonList(w, value)
+	if err != nil {
+		Logger.FromContext(r.Context()).Error("could not write response", "error", err)
+	}
+}
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// streamable reports whether value is a list that encodes the same element
+// by element as it does whole. Byte slices encode as strings and nil slices
+// as null.
+func streamable(value reflect.Value) bool {
+	return value.Kind() == reflect.Slice && !value.IsNil() &&
+		value.Type().Elem().Kind() != reflect.Uint8 &&
+		!value.Type().Implements(marshalerType)
+}
+
+func writeJsonList(w http.ResponseWriter, list reflect.Value) error {
+	encoder := json.NewEncoder(w)
+	_, err := w.Write([]byte("["))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < list.Len(); i++ {
+		if i > 0 {
+			_, err = w.Write([]byte(","))
+			if err != nil {
+				return err
+			}
+		}
+		err = encoder.Encode(list.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+	}
+	_, err = w.Write([]byte("]"))
+	return err
+}
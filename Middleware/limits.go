@@ -0,0 +1,18 @@
+package Middleware
+
+import "net/http"
+
+// MaxBodyMiddleware stops reading request bodies after limit bytes, so
+// decoding an oversized body fails instead of exhausting memory. A limit of 0
+// disables it.
+func MaxBodyMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
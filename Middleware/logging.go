@@ -0,0 +1,93 @@
+package Middleware
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"net/http"
+	"time"
+)
+
+// statusRecorder remembers the status and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// flushingStatusRecorder is a statusRecorder over a writer that can flush.
+// Only it implements http.Flusher, so handlers can still tell from the writer
+// whether the response can be streamed.
+type flushingStatusRecorder struct {
+	*statusRecorder
+}
+
+func (w flushingStatusRecorder) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// recordStatus wraps w in a statusRecorder, returning it along with the
+// writer to pass on, which can flush when w can.
+func recordStatus(w http.ResponseWriter) (*statusRecorder, http.ResponseWriter) {
+	recorder := &statusRecorder{ResponseWriter: w}
+	if _, ok := w.(http.Flusher); ok {
+		return recorder, flushingStatusRecorder{recorder}
+	}
+	return recorder, recorder
+}
+
+// routeTemplate is the path template of the matched route, or "unmatched"
+// for requests that matched none, so that probing random paths cannot grow
+// the request metrics without bound.
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
+// LoggingMiddleware puts a logger tagged with the request id on the request
+// context and writes one entry per request once it has been served. It must
+// run after RequestIdMiddleware.
+func LoggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		route := routeTemplate(r)
+		logger := Logger.Default.With("request_id", GetRequestId(r.Context()))
+		ctx := Logger.NewContext(r.Context(), logger)
+
+		recorder, writer := recordStatus(w)
+		next.ServeHTTP(writer, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		logger.Info("request",
+			"method", r.Method,
+			"route", route,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", recorder.bytes,
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+	})
+}
@@ -0,0 +1,23 @@
+package Middleware
+
+import (
+	"github.com/jonathanpatta/apartmentservices/Metrics"
+	"net/http"
+	"time"
+)
+
+// MetricsMiddleware counts and times requests by route template.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		recorder, writer := recordStatus(w)
+		next.ServeHTTP(writer, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		Metrics.ObserveRequest(r.Method, routeTemplate(r), status, time.Since(start))
+	})
+}
@@ -0,0 +1,31 @@
+package Middleware
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+const RequestIdHeader = "X-Request-Id"
+
+type requestIdKey struct{}
+
+func GetRequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// RequestIdMiddleware reuses the caller's X-Request-Id or assigns a new one,
+// echoes it back and stores it on the request context.
+func RequestIdMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIdHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIdHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestIdKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,68 @@
+package Middleware
+
+import (
+	"context"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"sync"
+	"time"
+)
+
+// maxSuspensionEntries bounds the users whose suspension is remembered, the
+// cache starts over once it is reached.
+const maxSuspensionEntries = 10000
+
+// suspensionCache keeps the answers of a SuspensionChecker for ttl, so that
+// authenticating a request does not cost a read. Suspensions made on other
+// instances take effect once the answer expires.
+type suspensionCache struct {
+	checker SuspensionChecker
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	entries map[string]suspensionEntry
+}
+
+type suspensionEntry struct {
+	suspended bool
+	checked   time.Time
+}
+
+func newSuspensionCache(checker SuspensionChecker, ttl time.Duration) *suspensionCache {
+	return &suspensionCache{
+		checker: checker,
+		ttl:     ttl,
+		entries: map[string]suspensionEntry{},
+	}
+}
+
+// IsSuspended reports whether the user is suspended. When the checker fails
+// the last answer is kept, and users never checked are let through, so that
+// the store being down does not lock everyone out.
+func (c *suspensionCache) IsSuspended(ctx context.Context, userId string) bool {
+	c.mu.Lock()
+	entry, found := c.entries[userId]
+	c.mu.Unlock()
+	if found && time.Since(entry.checked) < c.ttl {
+		return entry.suspended
+	}
+
+	suspended, err := c.checker.IsSuspended(userId)
+	if err != nil {
+		Logger.FromContext(ctx).Error("could not check suspension", "user_id", userId, "error", err)
+		return entry.suspended
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxSuspensionEntries {
+		c.entries = map[string]suspensionEntry{}
+	}
+	c.entries[userId] = suspensionEntry{suspended: suspended, checked: time.Now()}
+	return suspended
+}
+
+func (c *suspensionCache) forget(userId string) {
+	c.mu.Lock()
+	delete(c.entries, userId)
+	c.mu.Unlock()
+}
@@ -0,0 +1,355 @@
+package OpenApi
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation documents one method on one route.
+type Operation struct {
+	Summary string
+	Tag     string
+	// Auth marks routes behind Middleware.ValidateToken.
+	Auth bool
+	// Request and Response are zero values of the json bodies, nil when the
+	// route has none.
+	Request  interface{}
+	Response interface{}
+	// RequestContentType defaults to application/json.
+	RequestContentType string
+	// ResponseContentType defaults to application/json.
+	ResponseContentType string
+	// Status is the success status code, 200 when unset.
+	Status int
+	Query  []string
+}
+
+type Document struct {
+	OpenApi    string                                       `json:"openapi"`
+	Info       map[string]string                            `json:"info"`
+	Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+	Components map[string]interface{}                       `json:"components"`
+}
+
+var pathParam = regexp.MustCompile(`{([^}:]+)(:[^}]*)?}`)
+
+// OperationKey is how Operations are looked up for a route.
+func OperationKey(method string, path string) string {
+	return method + " " + path
+}
+
+// Routes lists every method and path template registered on the router,
+// skipping the OPTIONS preflight methods.
+func Routes(router *mux.Router) ([]string, error) {
+	var keys []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if method != http.MethodOptions {
+				keys = append(keys, OperationKey(method, path))
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// Undocumented returns the routes on the router that have no entry in
+// Operations.
+func Undocumented(router *mux.Router) ([]string, error) {
+	keys, err := Routes(router)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, key := range keys {
+		if _, ok := Operations[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing, nil
+}
+
+// Generate builds the OpenAPI 3 document for every route on the router.
+func Generate(router *mux.Router) (*Document, error) {
+	keys, err := Routes(router)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		schemas: map[string]interface{}{},
+		names:   map[reflect.Type]string{},
+	}
+	doc := &Document{
+		OpenApi: "3.0.3",
+		Info: map[string]string{
+			"title":   "Apartment Services",
+			"version": "1.0.0",
+		},
+		Paths: map[string]map[string]map[string]interface{}{},
+		Components: map[string]interface{}{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]interface{}{
+				"firebase": map[string]string{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "Firebase ID token",
+				},
+			},
+		},
+	}
+
+	for _, key := range keys {
+		op, ok := Operations[key]
+		if !ok {
+			continue
+		}
+		parts := strings.SplitN(key, " ", 2)
+		method, path := strings.ToLower(parts[0]), pathParam.ReplaceAllString(parts[1], "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]map[string]interface{}{}
+		}
+		doc.Paths[path][method] = g.operation(parts[1], op)
+	}
+
+	return doc, nil
+}
+
+type generator struct {
+	schemas map[string]interface{}
+	names   map[reflect.Type]string
+}
+
+func (g *generator) operation(path string, op Operation) map[string]interface{} {
+	out := map[string]interface{}{
+		"summary": op.Summary,
+	}
+	if op.Tag != "" {
+		out["tags"] = []string{op.Tag}
+	}
+
+	var params []interface{}
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]string{"type": "string"},
+		})
+	}
+	for _, name := range op.Query {
+		params = append(params, map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": map[string]string{"type": "string"},
+		})
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if op.Request != nil {
+		contentType := op.RequestContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		out["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				contentType: map[string]interface{}{
+					"schema": g.schema(reflect.TypeOf(op.Request)),
+				},
+			},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := map[string]interface{}{
+		"description": http.StatusText(status),
+	}
+	if op.Response != nil {
+		contentType := op.ResponseContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		response["content"] = map[string]interface{}{
+			contentType: map[string]interface{}{
+				"schema": g.schema(reflect.TypeOf(op.Response)),
+			},
+		}
+	}
+	out["responses"] = map[string]interface{}{
+		strconv.Itoa(status): response,
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": g.schema(reflect.TypeOf(Middleware.ErrorResponse{})),
+				},
+			},
+		},
+	}
+
+	if op.Auth {
+		out["security"] = []map[string][]string{{"firebase": {}}}
+	}
+
+	return out
+}
+
+// schema describes t, registering named structs as components.
+func (g *generator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		return map[string]interface{}{"type": "object"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := g.name(t)
+		if _, ok := g.schemas[name]; !ok {
+			// Reserve the name first so recursive types terminate.
+			g.schemas[name] = nil
+			g.schemas[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+// name picks a component name for t, qualifying it with its package when two
+// packages declare a type with the same name.
+func (g *generator) name(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for other, otherName := range g.names {
+		if otherName == name && other != t {
+			pkg := t.PkgPath()
+			name = pkg[strings.LastIndex(pkg, "/")+1:] + name
+			break
+		}
+	}
+	g.names[t] = name
+	return name
+}
+
+func (g *generator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	g.addFields(t, properties, &required)
+
+	out := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		out["required"] = required
+	}
+	return out
+}
+
+func (g *generator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			g.addFields(fieldType, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := g.schema(field.Type)
+		if _, isRef := schema["$ref"]; !isRef {
+			applyRules(schema, field.Tag.Get("validate"), name, required)
+		}
+		properties[name] = schema
+	}
+}
+
+// applyRules maps the Utils.Validate rules of a field onto its schema.
+func applyRules(schema map[string]interface{}, rules string, name string, required *[]string) {
+	if rules == "" {
+		return
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		ruleName, arg := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			ruleName, arg = rule[:i], rule[i+1:]
+		}
+		limit, _ := strconv.Atoi(arg)
+		switch ruleName {
+		case "required":
+			*required = append(*required, name)
+		case "oneof":
+			schema["enum"] = strings.Fields(arg)
+		case "min", "max":
+			var keyword string
+			switch schema["type"] {
+			case "string":
+				keyword = ruleName + "Length"
+			case "array":
+				keyword = ruleName + "Items"
+			default:
+				keyword = map[string]string{"min": "minimum", "max": "maximum"}[ruleName]
+			}
+			schema[keyword] = limit
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package OpenApi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"log"
+	"net/http"
+	"sync"
+)
+
+type OpenApiHttpService struct {
+	router *mux.Router
+
+	once sync.Once
+	spec []byte
+	err  error
+}
+
+func NewOpenApiHttpService(router *mux.Router) (*OpenApiHttpService, error) {
+	return &OpenApiHttpService{
+		router: router,
+	}, nil
+}
+
+// Read serves the document, generating it on first use so every route is
+// registered by then.
+func (s *OpenApiHttpService) Read(w http.ResponseWriter, r *http.Request) {
+	s.once.Do(func() {
+		doc, err := Generate(s.router)
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.spec, s.err = json.Marshal(doc)
+	})
+	if s.err != nil {
+		Middleware.WriteError(w, r, s.err)
+		return
+	}
+
+	_, err := fmt.Fprint(w, string(s.spec))
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+	}
+}
+
+// AddSubrouter serves /openapi.json and logs every route that has no entry in
+// Operations. It must be registered after every other subrouter.
+func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
+	server, err := NewOpenApiHttpService(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r.HandleFunc("/openapi.json", server.Read).Methods("GET", "OPTIONS")
+
+	missing, err := Undocumented(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, key := range missing {
+		Logger.Default.Warn("route is not documented in the openapi document", "route", key)
+	}
+}
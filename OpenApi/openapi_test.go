@@ -0,0 +1,111 @@
+package OpenApi_test
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Events"
+	"github.com/jonathanpatta/apartmentservices/Flags"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/OpenApi"
+	"github.com/jonathanpatta/apartmentservices/Router"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// stubSettings builds Settings whose clients are never called, which is
+// enough to register every route.
+func stubSettings(t *testing.T) *Settings.Settings {
+	cfg := aws.Config{Region: "us-east-1"}
+	middlewareService, err := Middleware.NewMiddlwareService(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := dynamodb.NewFromConfig(cfg)
+	tableName := aws.String("test")
+
+	return &Settings.Settings{
+		Config:            &Settings.Config{},
+		Dynamo:            &Settings.DynamoDbSettings{TableName: tableName, Cli: db},
+		S3Settings:        &Settings.S3Settings{BucketName: "test", Cli: s3.NewFromConfig(cfg), Region: cfg.Region},
+		MiddlewareService: middlewareService,
+		AwsCfg:            cfg,
+		Region:            cfg.Region,
+		Events:            Events.NewBus(Events.NewMemoryBroker()),
+		Flags:             Flags.NewFlagService(db, tableName, 0),
+	}
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	router := Router.NewRouter(stubSettings(t))
+
+	missing, err := OpenApi.Undocumented(router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range missing {
+		t.Errorf("route %v has no entry in OpenApi.Operations", key)
+	}
+}
+
+// TestAuthMatchesRouter checks Operation.Auth against the router, by sending
+// each route a request without a token and seeing whether it gets past the
+// middlewares.
+func TestAuthMatchesRouter(t *testing.T) {
+	router := Router.NewRouter(stubSettings(t))
+
+	reached := false
+	stub := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	})
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() != nil {
+			route.Handler(stub)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := OpenApi.Routes(router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pathVar := regexp.MustCompile(`{[^}]+}`)
+	for _, key := range keys {
+		op, ok := OpenApi.Operations[key]
+		if !ok {
+			continue
+		}
+		parts := strings.SplitN(key, " ", 2)
+		method, path := parts[0], pathVar.ReplaceAllString(parts[1], "x")
+		reached = false
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+
+		if op.Auth && (reached || w.Code != http.StatusUnauthorized) {
+			t.Errorf("%v is documented as authenticated but answered %v without a token", key, w.Code)
+		}
+		if !op.Auth && !reached {
+			t.Errorf("%v is documented as public but answered %v without a token", key, w.Code)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	router := Router.NewRouter(stubSettings(t))
+
+	doc, err := OpenApi.Generate(router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc == nil {
+		t.Fatal("expected a document")
+	}
+}
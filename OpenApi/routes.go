@@ -0,0 +1,682 @@
+package OpenApi
+
+import (
+	"github.com/jonathanpatta/apartmentservices/Admin"
+	"github.com/jonathanpatta/apartmentservices/ApiV2"
+	"github.com/jonathanpatta/apartmentservices/Consumers"
+	"github.com/jonathanpatta/apartmentservices/Events"
+	"github.com/jonathanpatta/apartmentservices/Files"
+	"github.com/jonathanpatta/apartmentservices/Flags"
+	"github.com/jonathanpatta/apartmentservices/GraphQL"
+	"github.com/jonathanpatta/apartmentservices/Health"
+	"github.com/jonathanpatta/apartmentservices/Items"
+	"github.com/jonathanpatta/apartmentservices/Me"
+	"github.com/jonathanpatta/apartmentservices/Messages"
+	"github.com/jonathanpatta/apartmentservices/Orders"
+	"github.com/jonathanpatta/apartmentservices/Producers"
+	"github.com/jonathanpatta/apartmentservices/Services"
+	"github.com/jonathanpatta/apartmentservices/Subscriptions"
+	"net/http"
+)
+
+// UploadImagesForm describes the multipart body of /files/uploadImages.
+type UploadImagesForm struct {
+	File []byte `json:"file"`
+}
+
+// Operations documents every route registered by Router.NewRouter, keyed by
+// OperationKey. A route without an entry is reported by Undocumented.
+var Operations = map[string]Operation{
+	"GET /consumer/list": {
+		Summary:  "List consumers",
+		Tag:      "consumers",
+		Response: []Consumers.Consumer{},
+	},
+	"POST /consumer/create": {
+		Summary:  "Create a consumer",
+		Tag:      "consumers",
+		Request:  Consumers.Consumer{},
+		Response: Consumers.Consumer{},
+	},
+	"POST /consumer/update": {
+		Summary:  "Update a consumer",
+		Tag:      "consumers",
+		Request:  Consumers.Consumer{},
+		Response: Consumers.Consumer{},
+	},
+	"POST /consumer/delete": {
+		Summary:  "Delete a consumer, the body is its id as a json string",
+		Tag:      "consumers",
+		Request:  "",
+		Response: Consumers.Consumer{},
+	},
+	"GET /consumer/{consumerId}": {
+		Summary:  "Read a consumer",
+		Tag:      "consumers",
+		Response: Consumers.Consumer{},
+	},
+	"POST /consumer/createOrGet": {
+		Summary:  "Return the consumer of a user, creating it when missing",
+		Tag:      "consumers",
+		Request:  Consumers.Consumer{},
+		Response: Consumers.Consumer{},
+	},
+	"GET /consumer/readFromUserId/{userId}": {
+		Summary:  "Read the consumer of a user",
+		Tag:      "consumers",
+		Response: Consumers.Consumer{},
+	},
+	"GET /producer/list": {
+		Summary:  "List producers",
+		Tag:      "producers",
+		Auth:     true,
+		Response: []Producers.Producer{},
+	},
+	"POST /producer/create": {
+		Summary:  "Create a producer",
+		Tag:      "producers",
+		Auth:     true,
+		Request:  Producers.Producer{},
+		Response: Producers.Producer{},
+	},
+	"POST /producer/update": {
+		Summary:  "Update a producer",
+		Tag:      "producers",
+		Auth:     true,
+		Request:  Producers.Producer{},
+		Response: Producers.Producer{},
+	},
+	"POST /producer/delete": {
+		Summary:  "Delete a producer, the body is its id as a json string",
+		Tag:      "producers",
+		Auth:     true,
+		Request:  "",
+		Response: Producers.Producer{},
+	},
+	"GET /producer/{producerId}": {
+		Summary:  "Read a producer",
+		Tag:      "producers",
+		Auth:     true,
+		Response: Producers.Producer{},
+	},
+	"POST /producer/createOrGet": {
+		Summary:  "Return the producer of a user, creating it when missing",
+		Tag:      "producers",
+		Auth:     true,
+		Request:  Producers.Producer{},
+		Response: Producers.Producer{},
+	},
+	"GET /producer/readFromUserId/{userId}": {
+		Summary:  "Read the producer of a user",
+		Tag:      "producers",
+		Auth:     true,
+		Response: Producers.Producer{},
+	},
+	"GET /producer/{producerId}/services": {
+		Summary:  "List the services of a producer",
+		Tag:      "producers",
+		Auth:     true,
+		Response: []Services.Service{},
+	},
+	"GET /producer/{producerId}/items": {
+		Summary:  "List every item of a producer",
+		Tag:      "producers",
+		Auth:     true,
+		Response: []Items.Item{},
+	},
+	"POST /producer/{producerId}/createItem": {
+		Summary:  "Create an item directly under a producer",
+		Tag:      "producers",
+		Auth:     true,
+		Request:  Items.Item{},
+		Response: Items.Item{},
+	},
+	"PUT /producer/{producerId}/storefront": {
+		Summary:  "Replace the storefront of a producer, only its owner can",
+		Tag:      "producers",
+		Auth:     true,
+		Request:  Producers.Storefront{},
+		Response: Producers.Producer{},
+	},
+	"GET /service/list": {
+		Summary:  "List services",
+		Tag:      "services",
+		Response: []Services.Service{},
+	},
+	"POST /service/create/{producerId}": {
+		Summary:  "Create a service",
+		Tag:      "services",
+		Request:  Services.Service{},
+		Response: Services.Service{},
+	},
+	"POST /service/update": {
+		Summary:  "Update a service",
+		Tag:      "services",
+		Request:  Services.Service{},
+		Response: Services.Service{},
+	},
+	"POST /service/delete": {
+		Summary:  "Delete a service, the body is its id as a json string",
+		Tag:      "services",
+		Request:  "",
+		Response: Services.Service{},
+	},
+	"GET /service/{serviceId}": {
+		Summary:  "Read a service",
+		Tag:      "services",
+		Response: Services.Service{},
+	},
+	"GET /service/{serviceId}/items": {
+		Summary:  "List the items of a service",
+		Tag:      "services",
+		Response: []Items.Item{},
+	},
+	"GET /item/list": {
+		Summary:  "List items",
+		Tag:      "items",
+		Response: []Items.Item{},
+	},
+	"POST /item/create/{serviceId}": {
+		Summary:  "Create an item",
+		Tag:      "items",
+		Request:  Items.Item{},
+		Response: Items.Item{},
+	},
+	"POST /item/update": {
+		Summary:  "Update an item",
+		Tag:      "items",
+		Request:  Items.Item{},
+		Response: Items.Item{},
+	},
+	"POST /item/delete": {
+		Summary:  "Delete an item, the body is its id as a json string",
+		Tag:      "items",
+		Request:  "",
+		Response: Items.Item{},
+	},
+	"GET /item/{itemId}": {
+		Summary:  "Read an item",
+		Tag:      "items",
+		Response: Items.Item{},
+	},
+	"GET /order/list": {
+		Summary:  "List orders",
+		Tag:      "orders",
+		Auth:     true,
+		Response: []Orders.Order{},
+	},
+	"POST /order/create/{consumerId}": {
+		Summary:  "Create an order",
+		Tag:      "orders",
+		Auth:     true,
+		Request:  Orders.Order{},
+		Response: Orders.Order{},
+	},
+	"POST /order/update": {
+		Summary:  "Update an order",
+		Tag:      "orders",
+		Auth:     true,
+		Request:  Orders.Order{},
+		Response: Orders.Order{},
+	},
+	"POST /order/delete": {
+		Summary:  "Delete an order, the body is its id as a json string",
+		Tag:      "orders",
+		Auth:     true,
+		Request:  "",
+		Response: Orders.Order{},
+	},
+	"GET /order/{orderId}": {
+		Summary:  "Read an order",
+		Tag:      "orders",
+		Auth:     true,
+		Response: Orders.Order{},
+	},
+	"GET /order/{orderId}/events": {
+		Summary:             "Stream the changes to an order as server-sent events, starting with an order.snapshot on every connection; Last-Event-ID is ignored",
+		Tag:                 "orders",
+		Auth:                true,
+		Response:            Events.Event{},
+		ResponseContentType: "text/event-stream",
+	},
+	"GET /order/producer/{producerId}/events": {
+		Summary:             "Stream the orders placed for a producer's items and their changes as server-sent events; Last-Event-ID is ignored, list the orders again after reconnecting",
+		Tag:                 "orders",
+		Auth:                true,
+		Response:            Events.Event{},
+		ResponseContentType: "text/event-stream",
+	},
+	"GET /thread/list": {
+		Summary:  "List the caller's message threads, the most recently active first",
+		Tag:      "messages",
+		Auth:     true,
+		Response: []Messages.Thread{},
+	},
+	"POST /thread/order/{orderId}": {
+		Summary:  "Open the thread about an order, creating it on first use",
+		Tag:      "messages",
+		Auth:     true,
+		Response: Messages.Thread{},
+	},
+	"POST /thread/subscription/{subscriptionId}": {
+		Summary:  "Open the thread about a subscription, creating it on first use",
+		Tag:      "messages",
+		Auth:     true,
+		Response: Messages.Thread{},
+	},
+	"GET /thread/{threadId}": {
+		Summary:  "Read a thread",
+		Tag:      "messages",
+		Auth:     true,
+		Response: Messages.Thread{},
+	},
+	"GET /thread/{threadId}/messages": {
+		Summary:  "List the messages of a thread, oldest first",
+		Tag:      "messages",
+		Auth:     true,
+		Response: []Messages.Message{},
+	},
+	"POST /thread/{threadId}/messages": {
+		Summary:  "Send a message, with a body, an image uploaded through /files or both",
+		Tag:      "messages",
+		Auth:     true,
+		Request:  Messages.Message{},
+		Response: Messages.Message{},
+	},
+	"POST /thread/{threadId}/read": {
+		Summary:  "Mark every message of a thread read by the caller",
+		Tag:      "messages",
+		Auth:     true,
+		Response: Messages.Thread{},
+	},
+	"GET /subscription/list": {
+		Summary:  "List subscriptions",
+		Tag:      "subscriptions",
+		Auth:     true,
+		Response: []Subscriptions.Subscription{},
+	},
+	"POST /subscription/create/{consumerId}": {
+		Summary:  "Create a subscription",
+		Tag:      "subscriptions",
+		Auth:     true,
+		Request:  Subscriptions.Subscription{},
+		Response: Subscriptions.Subscription{},
+	},
+	"POST /subscription/update": {
+		Summary:  "Update a subscription",
+		Tag:      "subscriptions",
+		Auth:     true,
+		Request:  Subscriptions.Subscription{},
+		Response: Subscriptions.Subscription{},
+	},
+	"POST /subscription/delete": {
+		Summary:  "Delete a subscription, the body is its id as a json string",
+		Tag:      "subscriptions",
+		Auth:     true,
+		Request:  "",
+		Response: Subscriptions.Subscription{},
+	},
+	"GET /subscription/{subscriptionId}": {
+		Summary:  "Read a subscription",
+		Tag:      "subscriptions",
+		Auth:     true,
+		Response: Subscriptions.Subscription{},
+	},
+	"POST /files/uploadImages": {
+		Summary:            "Upload images as multipart form files named file",
+		Tag:                "files",
+		Auth:               true,
+		Request:            UploadImagesForm{},
+		RequestContentType: "multipart/form-data",
+		Response:           []string{},
+	},
+	"POST /files/uploadImagesBase64": {
+		Summary:  "Upload base64 encoded images",
+		Tag:      "files",
+		Auth:     true,
+		Request:  []Files.Images{},
+		Response: []string{},
+	},
+	"GET /me": {
+		Summary:  "Read the caller's identity, linked records and counts",
+		Tag:      "me",
+		Auth:     true,
+		Response: Me.Profile{},
+	},
+	"DELETE /me": {
+		Summary:  "Anonymize and tombstone everything linked to the caller",
+		Tag:      "me",
+		Auth:     true,
+		Response: Me.ErasureResult{},
+	},
+	"GET /me/export": {
+		Summary:             "Download everything stored about the caller as a zip archive",
+		Tag:                 "me",
+		Auth:                true,
+		Response:            "",
+		ResponseContentType: "application/zip",
+	},
+	"GET /me/flags": {
+		Summary:  "List the feature flags on for the caller",
+		Tag:      "me",
+		Auth:     true,
+		Response: Me.FlagsOutput{},
+	},
+	"POST /admin/producer/{producerId}/suspend": {
+		Summary:  "Suspend a producer and block its user",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Admin.ModerationInput{},
+		Response: Producers.Producer{},
+	},
+	"POST /admin/producer/{producerId}/reinstate": {
+		Summary:  "Reinstate a suspended producer",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Admin.ModerationInput{},
+		Response: Producers.Producer{},
+	},
+	"POST /admin/consumer/{consumerId}/suspend": {
+		Summary:  "Suspend a consumer and block its user",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Admin.ModerationInput{},
+		Response: Consumers.Consumer{},
+	},
+	"POST /admin/consumer/{consumerId}/reinstate": {
+		Summary:  "Reinstate a suspended consumer",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Admin.ModerationInput{},
+		Response: Consumers.Consumer{},
+	},
+	"POST /admin/item/{itemId}/hide": {
+		Summary:  "Hide an item from every catalog listing",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Admin.ModerationInput{},
+		Response: Items.Item{},
+	},
+	"POST /admin/item/{itemId}/unhide": {
+		Summary:  "Make a hidden item visible again",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Admin.ModerationInput{},
+		Response: Items.Item{},
+	},
+	"PUT /admin/flag/{name}": {
+		Summary:  "Create a feature flag or replace who it targets",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Flags.FlagInput{},
+		Response: Flags.Flag{},
+	},
+	"DELETE /admin/flag/{name}": {
+		Summary:  "Delete a feature flag, turning it off for everyone",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Admin.ModerationInput{},
+		Response: Flags.Flag{},
+	},
+	"POST /admin/flag/{name}/enable": {
+		Summary:  "Turn a feature flag on for the users it targets",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Admin.ModerationInput{},
+		Response: Flags.Flag{},
+	},
+	"POST /admin/flag/{name}/disable": {
+		Summary:  "Turn a feature flag off for everyone",
+		Tag:      "admin",
+		Auth:     true,
+		Request:  Admin.ModerationInput{},
+		Response: Flags.Flag{},
+	},
+	"GET /admin/{entity}": {
+		Summary:  "List consumers, producers, services, items, orders, subscriptions, audit entries or flags",
+		Tag:      "admin",
+		Auth:     true,
+		Response: []map[string]interface{}{},
+		Query:    []string{"parent", "user_id", "created_by_user_id", "item_id", "include_deleted"},
+	},
+	"GET /v2/producers": {
+		Summary:  "List producers",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Response: []Producers.Producer{},
+	},
+	"POST /v2/producers": {
+		Summary:  "Create a producer",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Request:  Producers.Producer{},
+		Response: Producers.Producer{},
+		Status:   http.StatusCreated,
+	},
+	"GET /v2/producers/{producerId}": {
+		Summary:  "Read a producer",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Response: Producers.Producer{},
+	},
+	"PATCH /v2/producers/{producerId}": {
+		Summary:  "Update the given fields of a producer",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Request:  Producers.Producer{},
+		Response: Producers.Producer{},
+	},
+	"DELETE /v2/producers/{producerId}": {
+		Summary: "Delete a producer",
+		Tag:     "v2 producers",
+		Auth:    true,
+		Status:  http.StatusNoContent,
+	},
+	"GET /v2/producers/{producerId}/items": {
+		Summary:  "List every item of a producer",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Response: []Items.Item{},
+	},
+	"POST /v2/producers/{producerId}/items/bulk": {
+		Summary:  "Create, update and delete many items",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Request:  ApiV2.BulkRequest{},
+		Response: ApiV2.BulkResponse{},
+	},
+	"GET /v2/producers/{producerId}/services": {
+		Summary:  "List services",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Response: []Services.Service{},
+	},
+	"POST /v2/producers/{producerId}/services": {
+		Summary:  "Create a service",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Request:  Services.Service{},
+		Response: Services.Service{},
+		Status:   http.StatusCreated,
+	},
+	"POST /v2/producers/{producerId}/services/bulk": {
+		Summary:  "Create, update and delete many services",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Request:  ApiV2.BulkRequest{},
+		Response: ApiV2.BulkResponse{},
+	},
+	"GET /v2/producers/{producerId}/services/{serviceId}": {
+		Summary:  "Read a service",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Response: Services.Service{},
+	},
+	"PATCH /v2/producers/{producerId}/services/{serviceId}": {
+		Summary:  "Update the given fields of a service",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Request:  Services.Service{},
+		Response: Services.Service{},
+	},
+	"DELETE /v2/producers/{producerId}/services/{serviceId}": {
+		Summary: "Delete a service",
+		Tag:     "v2 producers",
+		Auth:    true,
+		Status:  http.StatusNoContent,
+	},
+	"GET /v2/producers/{producerId}/services/{serviceId}/items": {
+		Summary:  "List items",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Response: []Items.Item{},
+	},
+	"POST /v2/producers/{producerId}/services/{serviceId}/items": {
+		Summary:  "Create an item",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Request:  Items.Item{},
+		Response: Items.Item{},
+		Status:   http.StatusCreated,
+	},
+	"GET /v2/producers/{producerId}/services/{serviceId}/items/{itemId}": {
+		Summary:  "Read an item",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Response: Items.Item{},
+	},
+	"PATCH /v2/producers/{producerId}/services/{serviceId}/items/{itemId}": {
+		Summary:  "Update the given fields of an item",
+		Tag:      "v2 producers",
+		Auth:     true,
+		Request:  Items.Item{},
+		Response: Items.Item{},
+	},
+	"DELETE /v2/producers/{producerId}/services/{serviceId}/items/{itemId}": {
+		Summary: "Delete an item",
+		Tag:     "v2 producers",
+		Auth:    true,
+		Status:  http.StatusNoContent,
+	},
+	"GET /v2/consumers": {
+		Summary:  "List consumers",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Response: []Consumers.Consumer{},
+	},
+	"POST /v2/consumers": {
+		Summary:  "Create a consumer",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Request:  Consumers.Consumer{},
+		Response: Consumers.Consumer{},
+		Status:   http.StatusCreated,
+	},
+	"GET /v2/consumers/{consumerId}": {
+		Summary:  "Read a consumer",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Response: Consumers.Consumer{},
+	},
+	"PATCH /v2/consumers/{consumerId}": {
+		Summary:  "Update the given fields of a consumer",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Request:  Consumers.Consumer{},
+		Response: Consumers.Consumer{},
+	},
+	"DELETE /v2/consumers/{consumerId}": {
+		Summary: "Delete a consumer",
+		Tag:     "v2 consumers",
+		Auth:    true,
+		Status:  http.StatusNoContent,
+	},
+	"GET /v2/consumers/{consumerId}/orders": {
+		Summary:  "List orders",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Response: []Orders.Order{},
+	},
+	"POST /v2/consumers/{consumerId}/orders": {
+		Summary:  "Create an order",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Request:  Orders.Order{},
+		Response: Orders.Order{},
+		Status:   http.StatusCreated,
+	},
+	"GET /v2/consumers/{consumerId}/orders/{orderId}": {
+		Summary:  "Read an order",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Response: Orders.Order{},
+	},
+	"PATCH /v2/consumers/{consumerId}/orders/{orderId}": {
+		Summary:  "Update the given fields of an order",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Request:  Orders.Order{},
+		Response: Orders.Order{},
+	},
+	"DELETE /v2/consumers/{consumerId}/orders/{orderId}": {
+		Summary: "Delete an order",
+		Tag:     "v2 consumers",
+		Auth:    true,
+		Status:  http.StatusNoContent,
+	},
+	"GET /v2/consumers/{consumerId}/subscriptions": {
+		Summary:  "List subscriptions",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Response: []Subscriptions.Subscription{},
+	},
+	"POST /v2/consumers/{consumerId}/subscriptions": {
+		Summary:  "Create a subscription",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Request:  Subscriptions.Subscription{},
+		Response: Subscriptions.Subscription{},
+		Status:   http.StatusCreated,
+	},
+	"GET /v2/consumers/{consumerId}/subscriptions/{subscriptionId}": {
+		Summary:  "Read a subscription",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Response: Subscriptions.Subscription{},
+	},
+	"PATCH /v2/consumers/{consumerId}/subscriptions/{subscriptionId}": {
+		Summary:  "Update the given fields of a subscription",
+		Tag:      "v2 consumers",
+		Auth:     true,
+		Request:  Subscriptions.Subscription{},
+		Response: Subscriptions.Subscription{},
+	},
+	"DELETE /v2/consumers/{consumerId}/subscriptions/{subscriptionId}": {
+		Summary: "Delete a subscription",
+		Tag:     "v2 consumers",
+		Auth:    true,
+		Status:  http.StatusNoContent,
+	},
+	"POST /graphql": {
+		Summary:  "Run a GraphQL query over producers, services, items and consumers",
+		Tag:      "graphql",
+		Auth:     true,
+		Request:  GraphQL.Request{},
+		Response: map[string]interface{}{},
+	},
+	"GET /healthz": {
+		Summary:  "Report that the process is alive",
+		Tag:      "health",
+		Response: Health.Report{},
+	},
+	"GET /readyz": {
+		Summary:  "Check the table, files bucket and Firebase signing keys",
+		Tag:      "health",
+		Response: Health.Report{},
+	},
+	"GET /openapi.json": {
+		Summary:  "This document",
+		Tag:      "docs",
+		Response: map[string]interface{}{},
+	},
+}
@@ -4,14 +4,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Events"
+	"github.com/jonathanpatta/apartmentservices/Idempotency"
 	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Producers"
 	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
 	"log"
 	"net/http"
 )
 
 type OrderHttpService struct {
-	service *OrderService
+	service   *OrderService
+	producers *Producers.ProducerService
+	events    *Events.Bus
 }
 
 func NewOrderHttpService(settings *Settings.Settings) (*OrderHttpService, error) {
@@ -19,17 +25,23 @@ func NewOrderHttpService(settings *Settings.Settings) (*OrderHttpService, error)
 	if err != nil {
 		return nil, err
 	}
+	producers, err := Producers.NewProducerService(settings)
+	if err != nil {
+		return nil, err
+	}
 
 	return &OrderHttpService{
-		service: service,
+		service:   service,
+		producers: producers,
+		events:    settings.Events,
 	}, nil
 }
 
 func (s *OrderHttpService) Create(w http.ResponseWriter, r *http.Request) {
 	var data Order
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
@@ -40,21 +52,21 @@ func (s *OrderHttpService) Create(w http.ResponseWriter, r *http.Request) {
 	data.CreatedByUserEmail = user.Email
 	data.CreatedByUserPicture = user.Picture
 
-	order, err := s.service.Create(consumerId, &data)
+	order, err := s.service.Create(r.Context(), consumerId, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(order)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -66,72 +78,89 @@ func (s *OrderHttpService) Read(w http.ResponseWriter, r *http.Request) {
 
 	order, err := s.service.Read(data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	if Middleware.NotModified(w, r, Utils.ETagOf(order)) {
 		return
 	}
 
 	outData, err := json.Marshal(order)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *OrderHttpService) Update(w http.ResponseWriter, r *http.Request) {
 
 	var data Order
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		current, err := s.service.Read(data.SK)
+		if err != nil {
+			return nil, err
+		}
+		return &current.Meta, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	order, err := s.service.Update(&data)
+	order, err := s.service.Update(r.Context(), &data, ifVersion)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", Utils.ETagOf(order))
 
 	outData, err := json.Marshal(order)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *OrderHttpService) Delete(w http.ResponseWriter, r *http.Request) {
 	var data string
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	order, err := s.service.Delete(data)
+	order, err := s.service.Delete(r.Context(), data, nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(order)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -139,20 +168,42 @@ func (s *OrderHttpService) List(w http.ResponseWriter, r *http.Request) {
 
 	order, err := s.service.List()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	outData, err := json.Marshal(order)
+	if Middleware.NotModified(w, r, Utils.ETagOf(order)) {
+		return
+	}
+
+	Middleware.WriteJson(w, r, http.StatusOK, order)
+}
+
+// Events streams the changes to an order, starting with its current state.
+func (s *OrderHttpService) Events(w http.ResponseWriter, r *http.Request) {
+	orderId := mux.Vars(r)["orderId"]
+
+	order, err := s.service.Read(orderId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	_, err = fmt.Fprint(w, string(outData))
+	s.events.Stream(w, r, OrderTopic(order.SK), OrderSnapshot, order)
+}
+
+// ProducerEvents streams the orders placed for a producer's items and their
+// changes.
+func (s *OrderHttpService) ProducerEvents(w http.ResponseWriter, r *http.Request) {
+	producerId := mux.Vars(r)["producerId"]
+
+	producer, err := s.producers.Read(producerId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+		return
 	}
+
+	s.events.Stream(w, r, ProducerOrdersTopic(producer.SK), "", nil)
 }
 
 func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
@@ -162,11 +213,18 @@ func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
 	}
 	router := r.PathPrefix("/order").Subrouter()
 
+	idempotency, err := Idempotency.NewIdempotencyService(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	router.Use(settings.MiddlewareService.ValidateToken)
 
 	router.HandleFunc("/list", server.List).Methods("GET", "OPTIONS")
-	router.HandleFunc("/create/{consumerId}", server.Create).Methods("POST", "OPTIONS")
+	router.HandleFunc("/create/{consumerId}", idempotency.Handler(server.Create)).Methods("POST", "OPTIONS")
 	router.HandleFunc("/update", server.Update).Methods("POST", "OPTIONS")
 	router.HandleFunc("/delete", server.Delete).Methods("POST", "OPTIONS")
+	router.HandleFunc("/producer/{producerId}/events", server.ProducerEvents).Methods("GET", "OPTIONS")
+	router.HandleFunc("/{orderId}/events", server.Events).Methods("GET", "OPTIONS")
 	router.HandleFunc("/{orderId}", server.Read).Methods("GET", "OPTIONS")
 }
@@ -2,14 +2,16 @@ package Orders
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jonathanpatta/apartmentservices/Events"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Metrics"
 	"github.com/jonathanpatta/apartmentservices/Settings"
 	"github.com/jonathanpatta/apartmentservices/Utils"
+	"strings"
 )
 
 const ConsumerPrefix = "CONSUMER#"
@@ -22,9 +24,9 @@ type Consumer struct {
 
 type Order struct {
 	Utils.Meta
-	ItemId    string `json:"item_id,omitempty"`
-	ItemName  string `json:"item_name,omitempty"`
-	Note      string `json:"note,omitempty"`
+	ItemId    string `json:"item_id,omitempty" validate:"required"`
+	ItemName  string `json:"item_name,omitempty" validate:"max=100"`
+	Note      string `json:"note,omitempty" validate:"max=500"`
 	Completed string `json:"completed,omitempty"`
 
 	CreatedByUserId      string `json:"created_by_user_id,omitempty"`
@@ -36,18 +38,64 @@ type Order struct {
 type OrderService struct {
 	db               *dynamodb.Client
 	dynamodbSettings *Settings.DynamoDbSettings
+	events           *Events.Bus
 }
 
 const OrderPrefix = "ORDER#"
 
+// Event types published for every change to an order.
+const (
+	OrderCreated       = "order.created"
+	OrderUpdated       = "order.updated"
+	OrderStatusChanged = "order.status_changed"
+	OrderDeleted       = "order.deleted"
+	// OrderSnapshot is the current order, sent first on its event stream.
+	OrderSnapshot = "order.snapshot"
+)
+
+// OrderTopic carries the events of one order.
+func OrderTopic(orderId string) string {
+	return "order/" + orderId
+}
+
+// ProducerOrdersTopic carries the events of every order of a producer's
+// items.
+func ProducerOrdersTopic(producerId string) string {
+	return "producer/" + producerId + "/orders"
+}
+
+// producerOf is the producer an item id was created under.
+func producerOf(itemId string) string {
+	i := strings.Index(itemId, "_")
+	if i < 0 {
+		return ""
+	}
+	return itemId[:i]
+}
+
 func NewOrderService(settings *Settings.Settings) (*OrderService, error) {
 	return &OrderService{
 		db:               settings.Dynamo.Cli,
 		dynamodbSettings: settings.Dynamo,
+		events:           settings.Events,
 	}, nil
 }
 
-func (s *OrderService) Create(consumerId string, in *Order) (*Order, error) {
+// publish logs failures with the logger of ctx, but is not cancelled with
+// it, since the change has already been made.
+func (s *OrderService) publish(ctx context.Context, eventType string, order *Order) {
+	if s.events == nil {
+		return
+	}
+	ctx = Logger.NewContext(context.Background(), Logger.FromContext(ctx))
+	topics := []string{OrderTopic(order.SK)}
+	if producerId := producerOf(order.ItemId); producerId != "" {
+		topics = append(topics, ProducerOrdersTopic(producerId))
+	}
+	s.events.Publish(ctx, eventType, order, topics...)
+}
+
+func (s *OrderService) Create(ctx context.Context, consumerId string, in *Order) (*Order, error) {
 
 	err := s.ConsumerCheck(consumerId)
 	if err != nil {
@@ -64,7 +112,7 @@ func (s *OrderService) Create(consumerId string, in *Order) (*Order, error) {
 		return nil, err
 	}
 
-	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
 		Item:      order,
 		TableName: s.dynamodbSettings.TableName,
 	})
@@ -76,6 +124,8 @@ func (s *OrderService) Create(consumerId string, in *Order) (*Order, error) {
 	if err != nil {
 		return nil, err
 	}
+	Metrics.OrdersCreated.Inc()
+	s.publish(ctx, OrderCreated, in)
 
 	return in, nil
 }
@@ -107,13 +157,13 @@ func (s *OrderService) Read(orderId string) (*Order, error) {
 		return nil, err
 	}
 	if len(data) != 1 {
-		return nil, errors.New(fmt.Sprintf("length of return orders not 1 got %v", len(data)))
+		return nil, Utils.NotFoundOrAmbiguous("order", orderId, len(data))
 	}
 
 	return &data[0], nil
 }
 
-func (s *OrderService) Update(in *Order) (*Order, error) {
+func (s *OrderService) Update(ctx context.Context, in *Order, ifVersion *int64) (*Order, error) {
 
 	prevOrder, err := s.Read(in.SK)
 	if err != nil {
@@ -128,10 +178,7 @@ func (s *OrderService) Update(in *Order) (*Order, error) {
 		return nil, err
 	}
 
-	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
-		Item:      order,
-		TableName: s.dynamodbSettings.TableName,
-	})
+	err = s.dynamodbSettings.PutVersioned(order, ifVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -141,13 +188,72 @@ func (s *OrderService) Update(in *Order) (*Order, error) {
 		return nil, err
 	}
 
+	s.publish(ctx, OrderUpdated, in)
 	return in, nil
 }
 
+// SetStatus sets whether an order is completed. Update leaves the status
+// alone, so that /order/update keeps only changing the item.
+func (s *OrderService) SetStatus(ctx context.Context, orderId string, completed string, ifVersion *int64) (*Order, error) {
+	order, err := s.Read(orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	order.Completed = completed
+	order.SetLastModifiedNow()
+
+	item, err := attributevalue.MarshalMap(order)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.dynamodbSettings.PutVersioned(item, ifVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, OrderStatusChanged, order)
+	return order, nil
+}
+
 func (s *OrderService) List() ([]*Order, error) {
 
 	keyFilter := expression.Key("PK").Equal(expression.Value(OrderPrefix))
 
+	filter := expression.Name("IsDeleted").NotEqual(expression.Value(true))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.db.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data []*Order
+	err = attributevalue.UnmarshalListOfMaps(out.Items, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ListForConsumer returns every order placed by the given consumer.
+func (s *OrderService) ListForConsumer(consumerId string) ([]*Order, error) {
+
+	keyFilter := expression.Key("PK").Equal(expression.Value(OrderPrefix)).
+		And(expression.Key("SK").BeginsWith(consumerId))
+
 	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).Build()
 	if err != nil {
 		return nil, err
@@ -173,8 +279,54 @@ func (s *OrderService) List() ([]*Order, error) {
 	return data, nil
 }
 
-func (s *OrderService) Delete(orderId string) (*Order, error) {
-	return nil, nil
+func (s *OrderService) Delete(ctx context.Context, orderId string, ifVersion *int64) (*Order, error) {
+	order, err := s.Read(orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	order.IsDeleted = true
+	order.SetLastModifiedNow()
+
+	data, err := attributevalue.MarshalMap(order)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.dynamodbSettings.PutVersioned(data, ifVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, OrderDeleted, order)
+	return order, nil
+}
+
+// Erase strips the denormalized creator details and note from the order and
+// tombstones it.
+func (s *OrderService) Erase(ctx context.Context, in *Order) (*Order, error) {
+	in.CreatedByName = ""
+	in.CreatedByUserEmail = ""
+	in.CreatedByUserPicture = ""
+	in.Note = ""
+	in.IsDeleted = true
+	in.SetLastModifiedNow()
+
+	order, err := attributevalue.MarshalMap(in)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      order,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, OrderDeleted, in)
+	return in, nil
 }
 
 func (s *OrderService) ConsumerCheck(consumerId string) error {
@@ -203,7 +355,7 @@ func (s *OrderService) ConsumerCheck(consumerId string) error {
 		return err
 	}
 	if len(data) != 1 {
-		return errors.New(fmt.Sprintf("length of return orders not 1 got %v", len(data)))
+		return Utils.NotFoundOrAmbiguous("consumer", consumerId, len(data))
 	}
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/jonathanpatta/apartmentservices/Items"
 	"github.com/jonathanpatta/apartmentservices/Middleware"
 	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
 	"log"
 	"net/http"
 )
@@ -30,53 +31,53 @@ func NewProducerHttpService(settings *Settings.Settings) (*ProducerHttpService,
 
 func (s *ProducerHttpService) Create(w http.ResponseWriter, r *http.Request) {
 	var data Producer
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	producer, err := s.service.Create(&data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(producer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ProducerHttpService) CreateOrGet(w http.ResponseWriter, r *http.Request) {
 	var data Producer
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	producer, err := s.service.CreateOrGet(&data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(producer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -86,21 +87,25 @@ func (s *ProducerHttpService) Read(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	data = vars["producerId"]
 
-	producer, err := s.service.Read(data)
+	producer, err := s.service.ReadListed(data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	if Middleware.NotModified(w, r, Utils.ETagOf(producer)) {
 		return
 	}
 
 	outData, err := json.Marshal(producer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -112,72 +117,89 @@ func (s *ProducerHttpService) ReadFromUserId(w http.ResponseWriter, r *http.Requ
 
 	producer, err := s.service.ReadFromUserId(data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	if Middleware.NotModified(w, r, Utils.ETagOf(producer)) {
 		return
 	}
 
 	outData, err := json.Marshal(producer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ProducerHttpService) Update(w http.ResponseWriter, r *http.Request) {
 
 	var data Producer
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	producer, err := s.service.Update(&data)
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		current, err := s.service.Read(data.SK)
+		if err != nil {
+			return nil, err
+		}
+		return &current.Meta, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
+	producer, err := s.service.Update(&data, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", Utils.ETagOf(producer))
+
 	outData, err := json.Marshal(producer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ProducerHttpService) Delete(w http.ResponseWriter, r *http.Request) {
 	var data string
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	producer, err := s.service.Delete(data)
+	producer, err := s.service.Delete(data, nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(producer)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -185,20 +207,15 @@ func (s *ProducerHttpService) List(w http.ResponseWriter, r *http.Request) {
 
 	producer, err := s.service.List()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	outData, err := json.Marshal(producer)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if Middleware.NotModified(w, r, Utils.ETagOf(producer)) {
 		return
 	}
 
-	_, err = fmt.Fprint(w, string(outData))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	Middleware.WriteJson(w, r, http.StatusOK, producer)
 }
 
 func (s *ProducerHttpService) GetServices(w http.ResponseWriter, r *http.Request) {
@@ -206,20 +223,15 @@ func (s *ProducerHttpService) GetServices(w http.ResponseWriter, r *http.Request
 
 	producer, err := s.service.GetServices(producerId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	outData, err := json.Marshal(producer)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if Middleware.NotModified(w, r, Utils.ETagOf(producer)) {
 		return
 	}
 
-	_, err = fmt.Fprint(w, string(outData))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	Middleware.WriteJson(w, r, http.StatusOK, producer)
 }
 
 func (s *ProducerHttpService) GetAllItems(w http.ResponseWriter, r *http.Request) {
@@ -227,48 +239,76 @@ func (s *ProducerHttpService) GetAllItems(w http.ResponseWriter, r *http.Request
 
 	items, err := s.service.GetAllItems(producerId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	outData, err := json.Marshal(items)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if Middleware.NotModified(w, r, Utils.ETagOf(items)) {
 		return
 	}
 
-	_, err = fmt.Fprint(w, string(outData))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	Middleware.WriteJson(w, r, http.StatusOK, items)
 }
 
 func (s *ProducerHttpService) CreateItem(w http.ResponseWriter, r *http.Request) {
 	producerId := mux.Vars(r)["producerId"]
 
 	var data Items.Item
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	items, err := s.service.CreateItem(producerId, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(items)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+	}
+}
+
+func (s *ProducerHttpService) UpdateStorefront(w http.ResponseWriter, r *http.Request) {
+	user := Middleware.GetFirebaseUser(r.Context())
+	producerId := mux.Vars(r)["producerId"]
+
+	var data Storefront
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		current, err := s.service.Read(producerId)
+		if err != nil {
+			return nil, err
+		}
+		return &current.Meta, nil
+	})
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
 	}
+
+	producer, err := s.service.UpdateStorefront(user, producerId, &data, ifVersion)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", Utils.ETagOf(producer))
+
+	Middleware.WriteJson(w, r, http.StatusOK, producer)
 }
 
 func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
@@ -288,6 +328,7 @@ func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
 	router.HandleFunc("/{producerId}/services", server.GetServices).Methods("GET", "OPTIONS")
 	router.HandleFunc("/{producerId}/items", server.GetAllItems).Methods("GET", "OPTIONS")
 	router.HandleFunc("/{producerId}/createItem", server.CreateItem).Methods("POST", "OPTIONS")
+	router.HandleFunc("/{producerId}/storefront", server.UpdateStorefront).Methods("PUT", "OPTIONS")
 	router.HandleFunc("/{producerId}", server.Read).Methods("GET", "OPTIONS")
 	router.HandleFunc("/readFromUserId/{userId}", server.ReadFromUserId).Methods("GET", "OPTIONS")
 }
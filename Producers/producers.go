@@ -2,12 +2,11 @@ package Producers
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jonathanpatta/apartmentservices/Files"
 	"github.com/jonathanpatta/apartmentservices/Items"
 	"github.com/jonathanpatta/apartmentservices/Services"
 	"github.com/jonathanpatta/apartmentservices/Settings"
@@ -18,14 +17,18 @@ const ProducerPrefix = "PRODUCER#"
 
 type Producer struct {
 	Utils.Meta
-	UserId          string `json:"user_id,omitempty"`
-	ApartmentNumber string `json:"apartment_number,omitempty"`
+	UserId          string `json:"user_id,omitempty" validate:"max=128"`
+	ApartmentNumber string `json:"apartment_number,omitempty" validate:"max=20"`
+	Suspended       bool   `json:"suspended,omitempty"`
+	// Storefront is only changed through UpdateStorefront, by the owner.
+	Storefront Storefront `json:"storefront"`
 }
 
 type ProducerService struct {
 	db               *dynamodb.Client
 	dynamodbSettings *Settings.DynamoDbSettings
 	servicesCli      *Services.ServiceService
+	filesCli         *Files.S3FileService
 }
 
 func NewProducerService(settings *Settings.Settings) (*ProducerService, error) {
@@ -33,15 +36,21 @@ func NewProducerService(settings *Settings.Settings) (*ProducerService, error) {
 	if err != nil {
 		return nil, err
 	}
+	filesCli, err := Files.NewS3FileService(settings)
+	if err != nil {
+		return nil, err
+	}
 
 	return &ProducerService{
 		db:               settings.Dynamo.Cli,
 		dynamodbSettings: settings.Dynamo,
 		servicesCli:      servicesCli,
+		filesCli:         filesCli,
 	}, nil
 }
 
 func (s *ProducerService) Create(in *Producer) (*Producer, error) {
+	in.Storefront = Storefront{}
 	err := in.New(ProducerPrefix, "")
 	if err != nil {
 		return nil, err
@@ -74,6 +83,7 @@ func (s *ProducerService) CreateOrGet(in *Producer) (*Producer, error) {
 		return userIdProducer, nil
 	}
 
+	in.Storefront = Storefront{}
 	err = in.New(ProducerPrefix, "")
 	if err != nil {
 		return nil, err
@@ -99,6 +109,19 @@ func (s *ProducerService) CreateOrGet(in *Producer) (*Producer, error) {
 	return in, nil
 }
 
+// ReadListed is Read for the routes that show producers to users, to which
+// suspended producers are not found, as they are left out of List.
+func (s *ProducerService) ReadListed(producerId string) (*Producer, error) {
+	producer, err := s.Read(producerId)
+	if err != nil {
+		return nil, err
+	}
+	if producer.Suspended {
+		return nil, Utils.NewError(Utils.NotFound, "producer %v not found", producerId)
+	}
+	return producer, nil
+}
+
 func (s *ProducerService) Read(producerId string) (*Producer, error) {
 	keyFilter := expression.Key("PK").Equal(expression.Value(ProducerPrefix)).
 		And(expression.Key("SK").Equal(expression.Value(producerId)))
@@ -126,7 +149,7 @@ func (s *ProducerService) Read(producerId string) (*Producer, error) {
 		return nil, err
 	}
 	if len(data) != 1 {
-		return nil, errors.New(fmt.Sprintf("length of return items not 1 got %v", len(data)))
+		return nil, Utils.NotFoundOrAmbiguous("producer", producerId, len(data))
 	}
 
 	return &data[0], nil
@@ -160,13 +183,50 @@ func (s *ProducerService) ReadFromUserId(userId string) (*Producer, error) {
 		return nil, err
 	}
 	if len(data) != 1 {
-		return nil, errors.New(fmt.Sprintf("length of return items not 1 got %v", len(data)))
+		return nil, Utils.NotFoundOrAmbiguous("producer for user", userId, len(data))
 	}
 
 	return &data[0], nil
 }
 
-func (s *ProducerService) Update(in *Producer) (*Producer, error) {
+// FindFromUserId looks up the producer linked to a firebase user.
+//
+// Returns nil without an error when the user has no producer.
+func (s *ProducerService) FindFromUserId(userId string) (*Producer, error) {
+	keyFilter := expression.Key("PK").Equal(expression.Value(ProducerPrefix))
+
+	filterExpression := expression.Name("UserId").Equal(expression.Value(userId))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filterExpression).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.db.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+		ConsistentRead:            aws.Bool(false),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data []Producer
+	err = attributevalue.UnmarshalListOfMaps(out.Items, &data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	return &data[0], nil
+}
+
+func (s *ProducerService) Update(in *Producer, ifVersion *int64) (*Producer, error) {
 	producer, err := s.Read(in.SK)
 	if err != nil {
 		return nil, err
@@ -181,10 +241,7 @@ func (s *ProducerService) Update(in *Producer) (*Producer, error) {
 		return nil, err
 	}
 
-	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
-		Item:      item,
-		TableName: s.dynamodbSettings.TableName,
-	})
+	err = s.dynamodbSettings.PutVersioned(item, ifVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -197,11 +254,89 @@ func (s *ProducerService) Update(in *Producer) (*Producer, error) {
 	return in, nil
 }
 
+// Erase unlinks the producer from its firebase user and tombstones it
+// together with every service and item it offers.
+func (s *ProducerService) Erase(in *Producer) (*Producer, error) {
+	services, err := s.GetServices(in.SK)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.GetOwnedItems(in.SK)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []interface{}
+	for _, service := range services {
+		service.IsDeleted = true
+		service.SetLastModifiedNow()
+		records = append(records, service)
+	}
+	for _, item := range items {
+		item.IsDeleted = true
+		item.SetLastModifiedNow()
+		records = append(records, item)
+	}
+
+	in.UserId = ""
+	in.ApartmentNumber = ""
+	in.Storefront = Storefront{}
+	in.IsDeleted = true
+	in.SetLastModifiedNow()
+	records = append(records, in)
+
+	for _, record := range records {
+		item, err := attributevalue.MarshalMap(record)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+			Item:      item,
+			TableName: s.dynamodbSettings.TableName,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return in, nil
+}
+
+// SetSuspended marks the producer as suspended or reinstates it.
+func (s *ProducerService) SetSuspended(producerId string, suspended bool) (*Producer, error) {
+	producer, err := s.Read(producerId)
+	if err != nil {
+		return nil, err
+	}
+
+	producer.Suspended = suspended
+	producer.SetLastModifiedNow()
+
+	item, err := attributevalue.MarshalMap(producer)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return producer, nil
+}
+
 func (s *ProducerService) List() ([]*Producer, error) {
 
 	keyFilter := expression.Key("PK").Equal(expression.Value(ProducerPrefix))
 
-	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).Build()
+	filter := expression.Name("Suspended").NotEqual(expression.Value(true)).
+		And(expression.Name("IsDeleted").NotEqual(expression.Value(true)))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
 	if err != nil {
 		return nil, err
 	}
@@ -227,8 +362,26 @@ func (s *ProducerService) List() ([]*Producer, error) {
 	return data, nil
 }
 
-func (s *ProducerService) Delete(producerId string) (*Producer, error) {
-	return nil, nil
+func (s *ProducerService) Delete(producerId string, ifVersion *int64) (*Producer, error) {
+	producer, err := s.Read(producerId)
+	if err != nil {
+		return nil, err
+	}
+
+	producer.IsDeleted = true
+	producer.SetLastModifiedNow()
+
+	data, err := attributevalue.MarshalMap(producer)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.dynamodbSettings.PutVersioned(data, ifVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return producer, nil
 }
 
 type AddServiceInput struct {
@@ -309,7 +462,19 @@ func (s *ProducerService) CreateItem(producerId string, in *Items.Item) (*Items.
 	return in, nil
 }
 
+// GetAllItems returns the items a producer lists, leaving out those hidden
+// by an admin.
 func (s *ProducerService) GetAllItems(producerId string) ([]*Items.Item, error) {
+	return s.queryItems(producerId, false)
+}
+
+// GetOwnedItems returns every item of the producer that is not deleted,
+// including hidden ones, for the owner's export and erasure.
+func (s *ProducerService) GetOwnedItems(producerId string) ([]*Items.Item, error) {
+	return s.queryItems(producerId, true)
+}
+
+func (s *ProducerService) queryItems(producerId string, includeHidden bool) ([]*Items.Item, error) {
 	producer, err := s.Read(producerId)
 	if err != nil {
 		return nil, err
@@ -319,13 +484,16 @@ func (s *ProducerService) GetAllItems(producerId string) ([]*Items.Item, error)
 		And(expression.Key("SK").BeginsWith(producer.SK))
 
 	filter := expression.Name("IsDeleted").NotEqual(expression.Value(true))
+	if !includeHidden {
+		filter = filter.And(expression.Name("Hidden").NotEqual(expression.Value(true)))
+	}
 
 	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
 	if err != nil {
 		return nil, err
 	}
 
-	out, err := s.db.Query(context.TODO(), &dynamodb.QueryInput{
+	paginator := dynamodb.NewQueryPaginator(s.db, &dynamodb.QueryInput{
 		TableName:                 s.dynamodbSettings.TableName,
 		KeyConditionExpression:    expr.KeyCondition(),
 		FilterExpression:          expr.Filter(),
@@ -333,14 +501,19 @@ func (s *ProducerService) GetAllItems(producerId string) ([]*Items.Item, error)
 		ExpressionAttributeNames:  expr.Names(),
 		ConsistentRead:            aws.Bool(false),
 	})
-	if err != nil {
-		return nil, err
-	}
 
 	var data []*Items.Item
-	err = attributevalue.UnmarshalListOfMaps(out.Items, &data)
-	if err != nil {
-		return nil, err
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		var page []*Items.Item
+		err = attributevalue.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, page...)
 	}
 
 	return data, nil
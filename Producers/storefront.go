@@ -0,0 +1,131 @@
+package Producers
+
+import (
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"strconv"
+	"strings"
+)
+
+// Ways a producer can be reached, as listed on its storefront.
+const (
+	ContactInApp    = "in_app"
+	ContactPhone    = "phone"
+	ContactWhatsApp = "whatsapp"
+	ContactEmail    = "email"
+)
+
+const maxTagLength = 30
+
+// Storefront is how a producer presents itself in the app.
+type Storefront struct {
+	DisplayName string `json:"display_name,omitempty" validate:"max=80"`
+	Bio         string `json:"bio,omitempty" validate:"max=1000"`
+	// LogoUrl and BannerUrl are images the owner uploaded through
+	// /files/uploadImages.
+	LogoUrl   string           `json:"logo_url,omitempty" validate:"max=1024"`
+	BannerUrl string           `json:"banner_url,omitempty" validate:"max=1024"`
+	Contact   []*ContactOption `json:"contact,omitempty" validate:"max=10"`
+	// Tags are the cuisines and categories the producer is found under, such
+	// as "south-indian" or "baking". They are stored in lower case.
+	Tags []string `json:"tags,omitempty" validate:"max=20"`
+}
+
+// ContactOption is one way to reach the producer. Value is left empty for
+// in_app, which goes through message threads.
+type ContactOption struct {
+	Kind      string `json:"kind,omitempty" validate:"required,oneof=in_app phone whatsapp email"`
+	Value     string `json:"value,omitempty" validate:"max=200"`
+	Preferred bool   `json:"preferred,omitempty"`
+}
+
+// UpdateStorefront replaces the storefront of a producer. Only the user the
+// producer belongs to can change it.
+func (s *ProducerService) UpdateStorefront(user *Middleware.FirebaseUser, producerId string, in *Storefront, ifVersion *int64) (*Producer, error) {
+	producer, err := s.Read(producerId)
+	if err != nil {
+		return nil, err
+	}
+	if producer.IsDeleted {
+		return nil, Utils.NewError(Utils.NotFound, "producer %v not found", producerId)
+	}
+	if user.UserId == "" || producer.UserId != user.UserId {
+		return nil, Utils.NewError(Utils.Forbidden, "only the owner can edit the storefront of producer %v", producerId)
+	}
+
+	fields := map[string]string{}
+	for name, url := range map[string]string{"logo_url": in.LogoUrl, "banner_url": in.BannerUrl} {
+		if url != "" && !s.filesCli.IsUserImageUrl(user.UserId, url) {
+			fields[name] = "must be an image uploaded by the owner"
+		}
+	}
+	// Validate does not reach into the options through the Contact field.
+	for name, problem := range Utils.ErrorFieldsOf(Utils.Validate(in.Contact)) {
+		fields["contact"+name] = problem
+	}
+	for i, option := range in.Contact {
+		if option == nil {
+			fields["contact["+strconv.Itoa(i)+"]"] = "is required"
+			continue
+		}
+		switch option.Kind {
+		case ContactPhone, ContactWhatsApp, ContactEmail:
+			if strings.TrimSpace(option.Value) == "" {
+				fields["contact["+strconv.Itoa(i)+"].value"] = "is required for " + option.Kind
+			}
+		}
+	}
+	tags, problem := normalizeTags(in.Tags)
+	if problem != "" {
+		fields["tags"] = problem
+	}
+	if len(fields) > 0 {
+		return nil, &Utils.Error{
+			Code:    Utils.Validation,
+			Message: "invalid input",
+			Fields:  fields,
+		}
+	}
+
+	producer.Storefront = Storefront{
+		DisplayName: strings.TrimSpace(in.DisplayName),
+		Bio:         strings.TrimSpace(in.Bio),
+		LogoUrl:     in.LogoUrl,
+		BannerUrl:   in.BannerUrl,
+		Contact:     in.Contact,
+		Tags:        tags,
+	}
+	producer.SetLastModifiedNow()
+
+	item, err := attributevalue.MarshalMap(producer)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.dynamodbSettings.PutVersioned(item, ifVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return producer, nil
+}
+
+// normalizeTags lower cases and trims tags and drops empty and repeated
+// ones.
+func normalizeTags(tags []string) ([]string, string) {
+	var normalized []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, "each tag must have at most " + strconv.Itoa(maxTagLength) + " characters"
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, ""
+}
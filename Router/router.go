@@ -2,16 +2,25 @@ package Router
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Admin"
+	"github.com/jonathanpatta/apartmentservices/ApiV2"
 	"github.com/jonathanpatta/apartmentservices/Consumers"
 	"github.com/jonathanpatta/apartmentservices/Files"
+	"github.com/jonathanpatta/apartmentservices/GraphQL"
+	"github.com/jonathanpatta/apartmentservices/Health"
 	"github.com/jonathanpatta/apartmentservices/Items"
+	"github.com/jonathanpatta/apartmentservices/Me"
+	"github.com/jonathanpatta/apartmentservices/Messages"
 	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/OpenApi"
 	"github.com/jonathanpatta/apartmentservices/Orders"
 	"github.com/jonathanpatta/apartmentservices/Producers"
 	"github.com/jonathanpatta/apartmentservices/Services"
 	"github.com/jonathanpatta/apartmentservices/Settings"
 	"github.com/jonathanpatta/apartmentservices/Subscriptions"
+	"github.com/jonathanpatta/apartmentservices/Utils"
 	"log"
+	"net/http"
 )
 
 func GetMainRouter() *mux.Router {
@@ -20,16 +29,45 @@ func GetMainRouter() *mux.Router {
 		log.Fatalf("unable to load settings, %v", err)
 	}
 
+	return NewRouter(settings)
+}
+
+// NewRouter registers every route against already loaded settings.
+func NewRouter(settings *Settings.Settings) *mux.Router {
+	Admin.EnforceSuspensions(settings)
+
 	router := mux.NewRouter()
 	router.StrictSlash(true)
-	router.Use(Middleware.CorsMiddleware)
+
+	middlewares := []mux.MiddlewareFunc{
+		Middleware.RequestIdMiddleware,
+		Middleware.LoggingMiddleware,
+		Middleware.MetricsMiddleware,
+		Middleware.CorsMiddleware,
+	}
+	// mux does not run middlewares for requests that match no route.
+	var notFound http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Middleware.WriteError(w, r, Utils.NewError(Utils.NotFound, "no route for %v %v", r.Method, r.URL.Path))
+	})
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		notFound = middlewares[i](notFound)
+	}
+	router.NotFoundHandler = notFound
+	router.Use(middlewares...)
 	Consumers.AddSubrouter(router, settings)
 	Producers.AddSubrouter(router, settings)
 	Services.AddSubrouter(router, settings)
 	Items.AddSubrouter(router, settings)
 	Orders.AddSubrouter(router, settings)
 	Subscriptions.AddSubrouter(router, settings)
+	Messages.AddSubrouter(router, settings)
 	Files.AddSubrouter(router, settings)
+	Me.AddSubrouter(router, settings)
+	Admin.AddSubrouter(router, settings)
+	ApiV2.AddSubrouter(router, settings)
+	GraphQL.AddSubrouter(router, settings)
+	Health.AddSubrouter(router, settings)
+	OpenApi.AddSubrouter(router, settings)
 
 	return router
 }
@@ -0,0 +1,170 @@
+package Secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of Provider, as named by SECRETS_PROVIDER.
+const (
+	KindS3   = "s3"
+	KindFile = "file"
+	KindEnv  = "env"
+)
+
+// ErrNotFound is returned, wrapped, when a provider has no secret by the
+// requested name.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets, such as the firebase service account
+// credentials, by name.
+type Provider interface {
+	Get(ctx context.Context, name string) ([]byte, error)
+}
+
+// S3Provider reads each secret from the object of the same name in a bucket.
+type S3Provider struct {
+	cli    *s3.Client
+	bucket string
+}
+
+func NewS3Provider(cli *s3.Client, bucket string) *S3Provider {
+	return &S3Provider{cli: cli, bucket: bucket}
+}
+
+func (p *S3Provider) Get(ctx context.Context, name string) ([]byte, error) {
+	result, err := p.cli.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("%w: %v in bucket %v", ErrNotFound, name, p.bucket)
+		}
+		return nil, fmt.Errorf("reading secret %v from bucket %v: %v", name, p.bucket, err)
+	}
+	defer result.Body.Close()
+	return ioutil.ReadAll(result.Body)
+}
+
+// FileProvider reads each secret from the file of the same name in a
+// directory, which suits offline runs and mounted secret volumes.
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	// Names are relative to the directory and may not leave it.
+	clean := filepath.Clean(name)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("secret name %q is outside the secrets directory", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.dir, clean))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %v in %v", ErrNotFound, name, p.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading secret %v: %v", name, err)
+	}
+	return data, nil
+}
+
+// EnvProvider reads each secret from an environment variable, named after
+// the secret in upper case with the prefix and with every character other
+// than letters and digits replaced by "_". With the prefix SECRET_ the
+// secret firebase-auth.json is read from SECRET_FIREBASE_AUTH_JSON.
+type EnvProvider struct {
+	prefix string
+}
+
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix}
+}
+
+func (p *EnvProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	variable := p.Variable(name)
+	value, found := os.LookupEnv(variable)
+	if !found || value == "" {
+		return nil, fmt.Errorf("%w: %v in environment variable %v", ErrNotFound, name, variable)
+	}
+	return []byte(value), nil
+}
+
+// Variable is the environment variable the secret name is read from.
+func (p *EnvProvider) Variable(name string) string {
+	return p.prefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Cache keeps the secrets read from a Provider in memory. Secrets are kept
+// for ttl, or until the process exits when ttl is 0. Failed lookups are not
+// cached.
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   []byte
+	fetched time.Time
+}
+
+func NewCache(provider Provider, ttl time.Duration) *Cache {
+	return &Cache{
+		provider: provider,
+		ttl:      ttl,
+		entries:  map[string]cacheEntry{},
+	}
+}
+
+func (c *Cache) Get(ctx context.Context, name string) ([]byte, error) {
+	c.mu.Lock()
+	entry, found := c.entries[name]
+	c.mu.Unlock()
+	if found && (c.ttl == 0 || time.Since(entry.fetched) < c.ttl) {
+		return entry.value, nil
+	}
+
+	value, err := c.provider.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[name] = cacheEntry{value: value, fetched: time.Now()}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Forget drops a cached secret, so the next Get reads it again.
+func (c *Cache) Forget(name string) {
+	c.mu.Lock()
+	delete(c.entries, name)
+	c.mu.Unlock()
+}
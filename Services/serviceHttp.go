@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
 	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
 	"log"
 	"net/http"
 )
@@ -26,9 +28,9 @@ func NewServiceHttpService(settings *Settings.Settings) (*ServiceHttpService, er
 
 func (s *ServiceHttpService) Create(w http.ResponseWriter, r *http.Request) {
 	var data Service
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
@@ -36,19 +38,19 @@ func (s *ServiceHttpService) Create(w http.ResponseWriter, r *http.Request) {
 
 	service, err := s.service.Create(producerId, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(service)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -60,72 +62,89 @@ func (s *ServiceHttpService) Read(w http.ResponseWriter, r *http.Request) {
 
 	service, err := s.service.Read(data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	if Middleware.NotModified(w, r, Utils.ETagOf(service)) {
 		return
 	}
 
 	outData, err := json.Marshal(service)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ServiceHttpService) Update(w http.ResponseWriter, r *http.Request) {
 
 	var data Service
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		current, err := s.service.Read(data.SK)
+		if err != nil {
+			return nil, err
+		}
+		return &current.Meta, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	service, err := s.service.Update(&data)
+	service, err := s.service.Update(&data, ifVersion)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", Utils.ETagOf(service))
 
 	outData, err := json.Marshal(service)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *ServiceHttpService) Delete(w http.ResponseWriter, r *http.Request) {
 	var data string
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	service, err := s.service.Delete(data)
+	service, err := s.service.Delete(data, nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(service)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -133,20 +152,15 @@ func (s *ServiceHttpService) List(w http.ResponseWriter, r *http.Request) {
 
 	service, err := s.service.List()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	outData, err := json.Marshal(service)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if Middleware.NotModified(w, r, Utils.ETagOf(service)) {
 		return
 	}
 
-	_, err = fmt.Fprint(w, string(outData))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	Middleware.WriteJson(w, r, http.StatusOK, service)
 }
 
 func (s *ServiceHttpService) GetItems(w http.ResponseWriter, r *http.Request) {
@@ -155,20 +169,15 @@ func (s *ServiceHttpService) GetItems(w http.ResponseWriter, r *http.Request) {
 
 	producer, err := s.service.GetItems(serviceId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	outData, err := json.Marshal(producer)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if Middleware.NotModified(w, r, Utils.ETagOf(producer)) {
 		return
 	}
 
-	_, err = fmt.Fprint(w, string(outData))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	Middleware.WriteJson(w, r, http.StatusOK, producer)
 }
 
 func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
@@ -2,12 +2,11 @@ package Services
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/jonathanpatta/apartmentservices/Items"
 	"github.com/jonathanpatta/apartmentservices/Settings"
 	"github.com/jonathanpatta/apartmentservices/Utils"
@@ -17,7 +16,7 @@ const ServicePrefix = "SERVICE#"
 
 type Service struct {
 	Utils.Meta
-	Name string `json:"name"`
+	Name string `json:"name" validate:"required,max=100"`
 }
 
 const ProducerPrefix = "PRODUCER#"
@@ -41,7 +40,7 @@ func NewServiceService(settings *Settings.Settings) (*ServiceService, error) {
 
 func (s *ServiceService) Create(producerId string, in *Service) (*Service, error) {
 	if producerId == "" {
-		return nil, errors.New("producer id required")
+		return nil, Utils.NewError(Utils.Validation, "producer id required")
 	}
 	err := s.ProducerCheck(producerId)
 	if err != nil {
@@ -100,13 +99,13 @@ func (s *ServiceService) Read(serviceId string) (*Service, error) {
 		return nil, err
 	}
 	if len(data) != 1 {
-		return nil, errors.New(fmt.Sprintf("length of return items not 1 got %v", len(data)))
+		return nil, Utils.NotFoundOrAmbiguous("service", serviceId, len(data))
 	}
 
 	return &data[0], nil
 }
 
-func (s *ServiceService) Update(in *Service) (*Service, error) {
+func (s *ServiceService) Update(in *Service, ifVersion *int64) (*Service, error) {
 
 	service, err := s.Read(in.SK)
 	if err != nil {
@@ -121,10 +120,7 @@ func (s *ServiceService) Update(in *Service) (*Service, error) {
 		return nil, err
 	}
 
-	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
-		Item:      item,
-		TableName: s.dynamodbSettings.TableName,
-	})
+	err = s.dynamodbSettings.PutVersioned(item, ifVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +137,9 @@ func (s *ServiceService) List() ([]*Service, error) {
 
 	keyFilter := expression.Key("PK").Equal(expression.Value(ServicePrefix))
 
-	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).Build()
+	filter := expression.Name("IsDeleted").NotEqual(expression.Value(true))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
 	if err != nil {
 		return nil, err
 	}
@@ -166,8 +164,63 @@ func (s *ServiceService) List() ([]*Service, error) {
 	return data, nil
 }
 
-func (s *ServiceService) Delete(serviceId string) (*Service, error) {
-	return nil, nil
+// ListUnder returns the services created under a producer that are not
+// deleted, reading every page of the query.
+func (s *ServiceService) ListUnder(producerId string) ([]*Service, error) {
+	keyFilter := expression.Key("PK").Equal(expression.Value(ServicePrefix)).
+		And(expression.Key("SK").BeginsWith(producerId + "_"))
+
+	filter := expression.Name("IsDeleted").NotEqual(expression.Value(true))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(s.db, &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+	})
+
+	data := []*Service{}
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		var page []*Service
+		err = attributevalue.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, page...)
+	}
+	return data, nil
+}
+
+func (s *ServiceService) Delete(serviceId string, ifVersion *int64) (*Service, error) {
+	service, err := s.Read(serviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	service.IsDeleted = true
+	service.SetLastModifiedNow()
+
+	data, err := attributevalue.MarshalMap(service)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.dynamodbSettings.PutVersioned(data, ifVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return service, nil
 }
 
 func (s *ServiceService) GetItems(serviceId string) ([]*Items.Item, error) {
@@ -180,7 +233,8 @@ func (s *ServiceService) GetItems(serviceId string) ([]*Items.Item, error) {
 	keyFilter := expression.Key("PK").Equal(expression.Value(Items.ItemPrefix)).
 		And(expression.Key("SK").BeginsWith(producer.SK))
 
-	filter := expression.Name("IsDeleted").NotEqual(expression.Value(true))
+	filter := expression.Name("IsDeleted").NotEqual(expression.Value(true)).
+		And(expression.Name("Hidden").NotEqual(expression.Value(true)))
 
 	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
 	if err != nil {
@@ -208,6 +262,53 @@ func (s *ServiceService) GetItems(serviceId string) ([]*Items.Item, error) {
 	return data, nil
 }
 
+// ReadMany reads several services at once, keyed by id. Ids that do not exist
+// are left out.
+func (s *ServiceService) ReadMany(serviceIds []string) (map[string]*Service, error) {
+	records, err := s.dynamodbSettings.BatchGet(ServicePrefix, serviceIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []*Service
+	err = attributevalue.UnmarshalListOfMaps(records, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*Service, len(data))
+	for _, service := range data {
+		out[service.SK] = service
+	}
+	return out, nil
+}
+
+// PutMany writes several services in transactions. Like Update, a service with
+// an ifVersion is only written while the stored one is still at that
+// version. The returned errors line up with services and are nil for the ones
+// that were written.
+func (s *ServiceService) PutMany(services []*Service, ifVersions []*int64) []error {
+	errs := make([]error, len(services))
+	var records []map[string]types.AttributeValue
+	var versions []*int64
+	var indexes []int
+	for i, service := range services {
+		record, err := attributevalue.MarshalMap(service)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		records = append(records, record)
+		versions = append(versions, ifVersions[i])
+		indexes = append(indexes, i)
+	}
+
+	for i, err := range s.dynamodbSettings.PutVersionedMany(records, versions) {
+		errs[indexes[i]] = err
+	}
+	return errs
+}
+
 func (s *ServiceService) ProducerCheck(producerId string) error {
 	keyFilter := expression.Key("PK").Equal(expression.Value(ProducerPrefix)).
 		And(expression.Key("SK").Equal(expression.Value(producerId)))
@@ -234,7 +335,7 @@ func (s *ServiceService) ProducerCheck(producerId string) error {
 		return err
 	}
 	if len(data) != 1 {
-		return errors.New(fmt.Sprintf("length of return items not 1 got %v", len(data)))
+		return Utils.NotFoundOrAmbiguous("producer", producerId, len(data))
 	}
 	return nil
 }
@@ -0,0 +1,158 @@
+package Settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"time"
+)
+
+const (
+	// transactWriteSize and batchGetSize are the DynamoDB limits per call.
+	transactWriteSize = 100
+	batchGetSize      = 100
+	// batchAttempts bounds the retries of items DynamoDB left unprocessed or
+	// whose transaction was canceled by another record.
+	batchAttempts = 5
+)
+
+var errUnprocessed = errors.New("dynamodb left the request unprocessed after retries")
+
+// PutVersionedMany writes the records with TransactWriteItems, 100 at a
+// time. Like PutVersioned, a record with an ifVersion is only written while
+// the stored record is still at that Version, and otherwise fails with
+// PreconditionFailed. One record failing does not stop the others: the
+// transaction is retried without it. The returned errors line up with
+// records and are nil for records that were written.
+func (s *DynamoDbSettings) PutVersionedMany(records []map[string]types.AttributeValue, ifVersions []*int64) []error {
+	errs := make([]error, len(records))
+	for start := 0; start < len(records); start += transactWriteSize {
+		end := start + transactWriteSize
+		if end > len(records) {
+			end = len(records)
+		}
+
+		puts := map[int]*types.Put{}
+		var pending []int
+		for i := start; i < end; i++ {
+			put, err := s.versionedPut(records[i], ifVersions[i])
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			puts[i] = put
+			pending = append(pending, i)
+		}
+
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt == batchAttempts {
+				for _, i := range pending {
+					errs[i] = errUnprocessed
+				}
+				break
+			}
+			if attempt > 0 {
+				time.Sleep(time.Duration(50<<attempt) * time.Millisecond)
+			}
+
+			var writes []types.TransactWriteItem
+			for _, i := range pending {
+				writes = append(writes, types.TransactWriteItem{Put: puts[i]})
+			}
+			_, err := s.Cli.TransactWriteItems(context.Background(), &dynamodb.TransactWriteItemsInput{
+				TransactItems: writes,
+			})
+
+			var canceled *types.TransactionCanceledException
+			if err == nil {
+				break
+			}
+			if !errors.As(err, &canceled) || len(canceled.CancellationReasons) != len(pending) {
+				for _, i := range pending {
+					errs[i] = err
+				}
+				break
+			}
+
+			// Nothing was written, retry the records that did not fail.
+			var retry []int
+			for j, reason := range canceled.CancellationReasons {
+				i := pending[j]
+				switch code := aws.ToString(reason.Code); code {
+				case "", "None", "TransactionConflict":
+					retry = append(retry, i)
+				case "ConditionalCheckFailed":
+					errs[i] = Utils.NewError(Utils.PreconditionFailed, "record has changed since version %v", *ifVersions[i])
+				default:
+					errs[i] = fmt.Errorf("%v: %v", code, aws.ToString(reason.Message))
+				}
+			}
+			pending = retry
+		}
+	}
+	return errs
+}
+
+// versionedPut is the Put PutVersioned makes for the record.
+func (s *DynamoDbSettings) versionedPut(item map[string]types.AttributeValue, ifVersion *int64) (*types.Put, error) {
+	put := &types.Put{
+		Item:      item,
+		TableName: s.TableName,
+	}
+	if ifVersion == nil {
+		return put, nil
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(versionCondition(*ifVersion)).Build()
+	if err != nil {
+		return nil, err
+	}
+	put.ConditionExpression = expr.Condition()
+	put.ExpressionAttributeNames = expr.Names()
+	put.ExpressionAttributeValues = expr.Values()
+	return put, nil
+}
+
+// BatchGet reads the records with the given PK and SKs with BatchGetItem,
+// 100 at a time. SKs that do not exist are missing from the result.
+func (s *DynamoDbSettings) BatchGet(pk string, sks []string) ([]map[string]types.AttributeValue, error) {
+	var records []map[string]types.AttributeValue
+	for start := 0; start < len(sks); start += batchGetSize {
+		end := start + batchGetSize
+		if end > len(sks) {
+			end = len(sks)
+		}
+
+		var keys []map[string]types.AttributeValue
+		for _, sk := range sks[start:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: pk},
+				"SK": &types.AttributeValueMemberS{Value: sk},
+			})
+		}
+
+		request := map[string]types.KeysAndAttributes{*s.TableName: {Keys: keys}}
+		for attempt := 0; len(request) > 0; attempt++ {
+			if attempt == batchAttempts {
+				return nil, errUnprocessed
+			}
+			if attempt > 0 {
+				time.Sleep(time.Duration(50<<attempt) * time.Millisecond)
+			}
+			out, err := s.Cli.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+				RequestItems: request,
+			})
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, out.Responses[*s.TableName]...)
+			request = out.UnprocessedKeys
+		}
+	}
+	return records, nil
+}
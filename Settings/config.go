@@ -0,0 +1,268 @@
+package Settings
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is what the service needs to reach AWS and firebase. Each field is
+// read from, in increasing order of precedence, its default, the config
+// file, the environment variable named by its env tag and the matching
+// command line flag.
+//
+// The config file is json with the lower case env names as keys, such as
+// {"dynamo_table_name": "services"}, and is named by CONFIG_FILE or -config.
+type Config struct {
+	Region    string `env:"AWS_REGION_CODE" validate:"required"`
+	TableName string `env:"DYNAMO_TABLE_NAME" validate:"required"`
+	// DynamoEndpoint and S3Endpoint point the clients at stand-ins such as
+	// DynamoDB Local and MinIO instead of AWS.
+	DynamoEndpoint string `env:"DYNAMO_ENDPOINT" validate:"url"`
+	FilesBucket    string `env:"FILES_BUCKET_NAME" validate:"required"`
+	S3Endpoint     string `env:"S3_ENDPOINT" validate:"url"`
+	// S3UsePathStyle addresses buckets as endpoint/bucket rather than
+	// bucket.endpoint, which most S3 stand-ins need.
+	S3UsePathStyle bool `env:"S3_USE_PATH_STYLE"`
+
+	// SecretsProvider is where secrets are read from: objects in
+	// SecretsBucket, files in SecretsDir, or environment variables named
+	// after the secret and prefixed with SecretsEnvPrefix.
+	SecretsProvider  string `env:"SECRETS_PROVIDER" default:"s3" validate:"oneof=s3 file env"`
+	SecretsBucket    string `env:"SECRETS_BUCKET"`
+	SecretsDir       string `env:"SECRETS_DIR"`
+	SecretsEnvPrefix string `env:"SECRETS_ENV_PREFIX" default:"SECRET_"`
+	// SecretsCacheTTL is how long secrets are kept in memory, 0 keeps them
+	// until the process exits.
+	SecretsCacheTTL time.Duration `env:"SECRETS_CACHE_TTL"`
+	// FirebaseSecretsFile names the firebase service account credentials
+	// secret.
+	FirebaseSecretsFile string `env:"FIREBASE_AUTH_SECRETS_FILENAME" validate:"required"`
+
+	CreateProfilesOnRead bool          `env:"CREATE_PROFILES_ON_READ"`
+	IdempotencyRetention time.Duration `env:"IDEMPOTENCY_RETENTION" default:"24h"`
+	// IdempotencyLease is how long a request holds its Idempotency-Key
+	// before a retry can take it over, it should outlast the request timeout.
+	IdempotencyLease time.Duration `env:"IDEMPOTENCY_LEASE" default:"2m"`
+	// FlagsCacheTTL is how long feature flags are kept in memory between
+	// reads of the table.
+	FlagsCacheTTL time.Duration `env:"FLAGS_CACHE_TTL" default:"30s"`
+	// SuspensionCacheTTL is how long whether a user is suspended is kept in
+	// memory, suspensions made on other instances take up to this long.
+	SuspensionCacheTTL time.Duration `env:"SUSPENSION_CACHE_TTL" default:"30s"`
+}
+
+// ConfigError lists every invalid setting, keyed by env name.
+type ConfigError struct {
+	Problems map[string]string
+}
+
+func (e *ConfigError) Error() string {
+	var names []string
+	for name := range e.Problems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, name+" "+e.Problems[name])
+	}
+	return "invalid configuration: " + strings.Join(lines, "; ")
+}
+
+// ConfigFlags binds Config and ServerSettings to command line flags, named
+// after the env names in lower case with dashes, such as -dynamo-table-name.
+type ConfigFlags struct {
+	fs     *flag.FlagSet
+	file   *string
+	values map[string]*string
+}
+
+// NewConfigFlags registers the flags on fs. They are read by LoadConfig and
+// LoadServerSettings once fs has been parsed.
+func NewConfigFlags(fs *flag.FlagSet) *ConfigFlags {
+	f := &ConfigFlags{
+		fs:     fs,
+		file:   fs.String("config", "", "json config file, overrides CONFIG_FILE"),
+		values: map[string]*string{},
+	}
+	for _, target := range []interface{}{&Config{}, &ServerSettings{}} {
+		for _, field := range configFields(target) {
+			f.values[field.env] = fs.String(flagName(field.env), "", "overrides "+field.env)
+		}
+	}
+	return f
+}
+
+// lookup returns the value of the flag for an env name when it was set.
+func (f *ConfigFlags) lookup(env string) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+	set := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == flagName(env) {
+			set = true
+		}
+	})
+	return *f.values[env], set
+}
+
+func (f *ConfigFlags) configFile() string {
+	if f != nil && *f.file != "" {
+		return *f.file
+	}
+	return os.Getenv("CONFIG_FILE")
+}
+
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// LoadConfig reads and validates the Config. flags may be nil where there is
+// no command line, as in the lambda.
+func LoadConfig(flags *ConfigFlags) (*Config, error) {
+	config := &Config{}
+	problems := map[string]string{}
+	err := loadConfig(config, flags)
+	var configErr *ConfigError
+	if errors.As(err, &configErr) {
+		problems = configErr.Problems
+	} else if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case config.SecretsProvider == "s3" && config.SecretsBucket == "":
+		problems["SECRETS_BUCKET"] = "is required when SECRETS_PROVIDER is s3"
+	case config.SecretsProvider == "file" && config.SecretsDir == "":
+		problems["SECRETS_DIR"] = "is required when SECRETS_PROVIDER is file"
+	}
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
+	}
+	return config, nil
+}
+
+type configField struct {
+	name  string
+	env   string
+	def   string
+	value reflect.Value
+}
+
+func configFields(target interface{}) []configField {
+	v := reflect.ValueOf(target).Elem()
+	var fields []configField
+	for i := 0; i < v.NumField(); i++ {
+		tag := v.Type().Field(i).Tag
+		env := tag.Get("env")
+		if env == "" {
+			continue
+		}
+		fields = append(fields, configField{
+			name:  v.Type().Field(i).Name,
+			env:   env,
+			def:   tag.Get("default"),
+			value: v.Field(i),
+		})
+	}
+	return fields
+}
+
+// loadConfig fills the env tagged fields of target, which must be a pointer
+// to a struct, and validates them with the rules in their validate tags.
+func loadConfig(target interface{}, flags *ConfigFlags) error {
+	// .env only fills variables that are not set already.
+	godotenv.Load()
+
+	file := map[string]interface{}{}
+	if name := flags.configFile(); name != "" {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("reading config file: %v", err)
+		}
+		err = json.Unmarshal(data, &file)
+		if err != nil {
+			return fmt.Errorf("reading config file %v: %v", name, err)
+		}
+	}
+
+	problems := map[string]string{}
+	envs := map[string]string{}
+	for _, field := range configFields(target) {
+		envs[field.name] = field.env
+		raw := field.def
+		if value, found := file[strings.ToLower(field.env)]; found {
+			raw = fmt.Sprint(value)
+			if number, ok := value.(float64); ok {
+				// Keep large numbers out of exponent notation.
+				raw = strconv.FormatFloat(number, 'f', -1, 64)
+			}
+		}
+		if value := os.Getenv(field.env); value != "" {
+			raw = value
+		}
+		if value, found := flags.lookup(field.env); found {
+			raw = value
+		}
+
+		err := setConfigValue(field.value, raw)
+		if err != nil {
+			problems[field.env] = err.Error()
+		}
+	}
+
+	// Config has no json tags, so invalid fields are named as in Go.
+	var invalid *Utils.Error
+	if errors.As(Utils.Validate(target), &invalid) {
+		for name, problem := range invalid.Fields {
+			if _, found := problems[envs[name]]; !found {
+				problems[envs[name]] = problem
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+func setConfigValue(value reflect.Value, raw string) error {
+	if raw == "" {
+		return nil
+	}
+	switch {
+	case value.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return fmt.Errorf("must be a positive duration such as 30s, got %q", raw)
+		}
+		value.SetInt(int64(d))
+	case value.Kind() == reflect.String:
+		value.SetString(raw)
+	case value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", raw)
+		}
+		value.SetBool(b)
+	case value.Kind() == reflect.Int || value.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("must be a positive number, got %q", raw)
+		}
+		value.SetInt(n)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package Settings
+
+import (
+	"fmt"
+	"time"
+)
+
+// ServerSettings configures the standalone http server in main.go. It is
+// loaded separately from Settings so the server can start, and report that
+// it is not ready, even when the AWS settings fail to load. Fields are read
+// like those of Config.
+type ServerSettings struct {
+	Addr string `env:"SERVER_ADDR" default:":8000"`
+	// GrpcAddr is where the gRPC API listens, it is not served when empty.
+	GrpcAddr string `env:"SERVER_GRPC_ADDR"`
+
+	ReadTimeout       time.Duration `env:"SERVER_READ_TIMEOUT" default:"30s"`
+	ReadHeaderTimeout time.Duration `env:"SERVER_READ_HEADER_TIMEOUT" default:"10s"`
+	IdleTimeout       time.Duration `env:"SERVER_IDLE_TIMEOUT" default:"120s"`
+	// WriteTimeout is off by default, since it would also cut the event
+	// streams of /order/{orderId}/events and
+	// /order/producer/{producerId}/events, which stay open for as long as the
+	// client listens.
+	WriteTimeout time.Duration `env:"SERVER_WRITE_TIMEOUT"`
+
+	// ShutdownGracePeriod is how long in-flight requests get to finish after
+	// SIGTERM before the server closes them.
+	ShutdownGracePeriod time.Duration `env:"SERVER_SHUTDOWN_GRACE_PERIOD" default:"25s"`
+
+	MaxHeaderBytes int `env:"SERVER_MAX_HEADER_BYTES" default:"1048576"`
+	// MaxBodyBytes limits request bodies, 0 means unlimited.
+	MaxBodyBytes int64 `env:"SERVER_MAX_BODY_BYTES" default:"33554432"`
+
+	// TLSCertFile and TLSKeyFile switch the server to https when both are set.
+	TLSCertFile string `env:"SERVER_TLS_CERT_FILE"`
+	TLSKeyFile  string `env:"SERVER_TLS_KEY_FILE"`
+}
+
+func NewServerSettings() (*ServerSettings, error) {
+	return LoadServerSettings(nil)
+}
+
+// LoadServerSettings reads and validates the ServerSettings, flags may be
+// nil.
+func LoadServerSettings(flags *ConfigFlags) (*ServerSettings, error) {
+	s := &ServerSettings{}
+	err := loadConfig(s, flags)
+	if err != nil {
+		return nil, err
+	}
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		return nil, fmt.Errorf("SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE must be set together")
+	}
+	return s, nil
+}
@@ -9,49 +9,78 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/joho/godotenv"
+	"github.com/jonathanpatta/apartmentservices/Events"
+	"github.com/jonathanpatta/apartmentservices/Flags"
+	"github.com/jonathanpatta/apartmentservices/Metrics"
 	"github.com/jonathanpatta/apartmentservices/Middleware"
+	"github.com/jonathanpatta/apartmentservices/Secrets"
 	"google.golang.org/api/option"
-	"io/ioutil"
-	"os"
+	"strings"
+	"time"
 )
 
 type Settings struct {
+	Config            *Config
 	Dynamo            *DynamoDbSettings
 	S3Settings        *S3Settings
 	FirebaseAuth      *FirebaseAuthSettings
+	Secrets           Secrets.Provider
 	Region            string
 	AwsCfg            aws.Config
 	MiddlewareService *Middleware.MiddlwareService
+	// Events carries record changes to event streams. It is in-process by
+	// default, set it to a bus over a shared broker when running more than
+	// one instance.
+	Events *Events.Bus
+	// Flags gates features being rolled out.
+	Flags *Flags.FlagService
+
+	// CreateProfilesOnRead makes /me create the caller's consumer and
+	// producer records when they do not exist yet.
+	CreateProfilesOnRead bool
+
+	// IdempotencyRetention is how long the response to a request made with
+	// an Idempotency-Key is kept for replaying to retries.
+	IdempotencyRetention time.Duration
+	// IdempotencyLease is how long a request that has not completed holds
+	// its Idempotency-Key, so a retry can take over from a request whose
+	// process died.
+	IdempotencyLease time.Duration
 }
 
+// NewSettings loads the Config from the environment and the config file
+// named by CONFIG_FILE, and connects to what it names.
 func NewSettings() (*Settings, error) {
+	c, err := LoadConfig(nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewSettingsFromConfig(c)
+}
 
-	err := godotenv.Load()
+func NewSettingsFromConfig(c *Config) (*Settings, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(c.Region))
 	if err != nil {
-		fmt.Println("could not read from .env file")
+		return nil, err
 	}
-	tableName := os.Getenv("DYNAMO_TABLE_NAME")
-	region := os.Getenv("AWS_REGION_CODE")
+	Metrics.InstrumentAWS(&cfg.APIOptions)
 
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+	s3Settings, err := NewS3Settings(cfg, c.FilesBucket, c.S3Endpoint, c.S3UsePathStyle)
 	if err != nil {
 		return nil, err
 	}
 
-	s3Client := s3.NewFromConfig(cfg)
-	bucketName := os.Getenv("FILES_BUCKET_NAME")
-	s3Settings, err := NewS3Settings(cfg, bucketName)
+	secrets, err := NewSecretsProvider(c, s3Settings.Cli)
 	if err != nil {
 		return nil, err
 	}
 
-	firebaseAuthSettings, err := NewFirebaseAuthSettings(s3Client)
+	firebaseAuthSettings, err := NewFirebaseAuthSettings(secrets, c.FirebaseSecretsFile)
 	if err != nil {
 		return nil, err
 	}
 
-	dynoDbSettings, err := NewDynamoDbSettings(cfg, tableName)
+	dynoDbSettings, err := NewDynamoDbSettings(cfg, c.TableName, c.DynamoEndpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -62,12 +91,19 @@ func NewSettings() (*Settings, error) {
 	}
 
 	return &Settings{
-		Dynamo:            dynoDbSettings,
-		FirebaseAuth:      firebaseAuthSettings,
-		MiddlewareService: middlewareService,
-		S3Settings:        s3Settings,
-		AwsCfg:            cfg,
-		Region:            region,
+		Config:               c,
+		Secrets:              secrets,
+		Dynamo:               dynoDbSettings,
+		FirebaseAuth:         firebaseAuthSettings,
+		MiddlewareService:    middlewareService,
+		S3Settings:           s3Settings,
+		AwsCfg:               cfg,
+		Region:               c.Region,
+		CreateProfilesOnRead: c.CreateProfilesOnRead,
+		IdempotencyRetention: c.IdempotencyRetention,
+		IdempotencyLease:     c.IdempotencyLease,
+		Events:               Events.NewBus(Events.NewMemoryBroker()),
+		Flags:                Flags.NewFlagService(dynoDbSettings.Cli, dynoDbSettings.TableName, c.FlagsCacheTTL),
 	}, nil
 }
 
@@ -76,8 +112,14 @@ type DynamoDbSettings struct {
 	Cli       *dynamodb.Client
 }
 
-func NewDynamoDbSettings(cfg aws.Config, TableName string) (*DynamoDbSettings, error) {
-	dynamoDbCli := dynamodb.NewFromConfig(cfg)
+// NewDynamoDbSettings connects to DynamoDB, or to the endpoint when it is
+// set.
+func NewDynamoDbSettings(cfg aws.Config, TableName string, endpoint string) (*DynamoDbSettings, error) {
+	dynamoDbCli := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if endpoint != "" {
+			o.EndpointResolver = dynamodb.EndpointResolverFromURL(endpoint)
+		}
+	})
 	return &DynamoDbSettings{
 		TableName: aws.String(TableName),
 		Cli:       dynamoDbCli,
@@ -87,43 +129,83 @@ func NewDynamoDbSettings(cfg aws.Config, TableName string) (*DynamoDbSettings, e
 type S3Settings struct {
 	BucketName string
 	Cli        *s3.Client
+	Region     string
+	// Endpoint is set when S3 is a stand-in rather than AWS.
+	Endpoint     string
+	UsePathStyle bool
 }
 
-func NewS3Settings(cfg aws.Config, BucketName string) (*S3Settings, error) {
-	cli := s3.NewFromConfig(cfg)
+// NewS3Settings connects to S3, or to the endpoint when it is set.
+func NewS3Settings(cfg aws.Config, BucketName string, endpoint string, usePathStyle bool) (*S3Settings, error) {
+	cli := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.EndpointResolver = s3.EndpointResolverFromURL(endpoint)
+		}
+		o.UsePathStyle = usePathStyle
+	})
 	return &S3Settings{
-		BucketName: BucketName,
-		Cli:        cli,
+		BucketName:   BucketName,
+		Cli:          cli,
+		Region:       cfg.Region,
+		Endpoint:     endpoint,
+		UsePathStyle: usePathStyle,
 	}, nil
 }
 
+// ObjectUrl is the public url of an object in the bucket.
+func (s *S3Settings) ObjectUrl(key string) string {
+	if s.Endpoint == "" {
+		return fmt.Sprintf("https://%v.s3-%v.amazonaws.com/%v", s.BucketName, s.Region, key)
+	}
+	endpoint := strings.TrimRight(s.Endpoint, "/")
+	if s.UsePathStyle {
+		return fmt.Sprintf("%v/%v/%v", endpoint, s.BucketName, key)
+	}
+	scheme, host := "https", endpoint
+	if i := strings.Index(endpoint, "://"); i >= 0 {
+		scheme, host = endpoint[:i], endpoint[i+3:]
+	}
+	return fmt.Sprintf("%v://%v.%v/%v", scheme, s.BucketName, host, key)
+}
+
 type FirebaseAuthSettings struct {
 	App  *firebase.App
 	Auth *auth.Client
 }
 
-func NewFirebaseAuthSettings(s3Client *s3.Client) (*FirebaseAuthSettings, error) {
-	result, err := s3Client.GetObject(context.TODO(), &s3.GetObjectInput{
-		Bucket: aws.String(os.Getenv("SECRETS_BUCKET")),
-		Key:    aws.String(os.Getenv("FIREBASE_AUTH_SECRETS_FILENAME")),
-	})
-	if err != nil {
-		return nil, err
+// NewSecretsProvider returns the cached Provider named by
+// c.SecretsProvider.
+func NewSecretsProvider(c *Config, s3Client *s3.Client) (Secrets.Provider, error) {
+	var provider Secrets.Provider
+	switch c.SecretsProvider {
+	case Secrets.KindS3:
+		provider = Secrets.NewS3Provider(s3Client, c.SecretsBucket)
+	case Secrets.KindFile:
+		provider = Secrets.NewFileProvider(c.SecretsDir)
+	case Secrets.KindEnv:
+		provider = Secrets.NewEnvProvider(c.SecretsEnvPrefix)
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q", c.SecretsProvider)
 	}
+	return Secrets.NewCache(provider, c.SecretsCacheTTL), nil
+}
 
-	body, err := ioutil.ReadAll(result.Body)
+// NewFirebaseAuthSettings sets up firebase with the service account
+// credentials read from the named secret.
+func NewFirebaseAuthSettings(secrets Secrets.Provider, name string) (*FirebaseAuthSettings, error) {
+	credentials, err := secrets.Get(context.TODO(), name)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("loading firebase credentials: %w", err)
 	}
 
-	opt := option.WithCredentialsJSON(body)
+	opt := option.WithCredentialsJSON(credentials)
 	app, err := firebase.NewApp(context.Background(), nil, opt)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing app: %v", err)
 	}
 	auth, err := app.Auth(context.Background())
 	if err != nil {
-		panic("Firebase load error")
+		return nil, fmt.Errorf("error initializing firebase auth from %v: %v", name, err)
 	}
 	return &FirebaseAuthSettings{
 		App:  app,
@@ -0,0 +1,48 @@
+package Settings
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jonathanpatta/apartmentservices/Utils"
+)
+
+// PutVersioned writes a record. When ifVersion is set the write only
+// happens if the stored record is still at that Version, and otherwise fails
+// with PreconditionFailed, so two writers holding the same ETag cannot both
+// succeed.
+func (s *DynamoDbSettings) PutVersioned(item map[string]types.AttributeValue, ifVersion *int64) error {
+	input := &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: s.TableName,
+	}
+
+	if ifVersion != nil {
+		expr, err := expression.NewBuilder().WithCondition(versionCondition(*ifVersion)).Build()
+		if err != nil {
+			return err
+		}
+		input.ConditionExpression = expr.Condition()
+		input.ExpressionAttributeNames = expr.Names()
+		input.ExpressionAttributeValues = expr.Values()
+	}
+
+	_, err := s.Cli.PutItem(context.Background(), input)
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return Utils.NewError(Utils.PreconditionFailed, "record has changed since version %v", *ifVersion)
+	}
+	return err
+}
+
+// versionCondition holds while the record exists at the given Version.
+func versionCondition(version int64) expression.ConditionBuilder {
+	condition := expression.Name("Version").Equal(expression.Value(version))
+	if version == 0 {
+		// Version is omitted while it is 0.
+		condition = condition.Or(expression.AttributeNotExists(expression.Name("Version")))
+	}
+	return expression.AttributeExists(expression.Name("SK")).And(condition)
+}
@@ -2,12 +2,11 @@ package Subscriptions
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jonathanpatta/apartmentservices/Metrics"
 	"github.com/jonathanpatta/apartmentservices/Settings"
 	"github.com/jonathanpatta/apartmentservices/Utils"
 )
@@ -22,10 +21,10 @@ type Consumer struct {
 
 type Subscription struct {
 	Utils.Meta
-	ItemId        string `json:"item_id,omitempty"`
-	ItemName      string `json:"item_name,omitempty"`
-	Note          string `json:"note,omitempty"`
-	RecurringType string `json:"recurring_type,omitempty"`
+	ItemId        string `json:"item_id,omitempty" validate:"required"`
+	ItemName      string `json:"item_name,omitempty" validate:"max=100"`
+	Note          string `json:"note,omitempty" validate:"max=500"`
+	RecurringType string `json:"recurring_type,omitempty" validate:"required,oneof=daily weekly monthly"`
 	Cancelled     bool   `json:"cancelled,omitempty"`
 
 	CreatedByUserId      string `json:"created_by_user_id,omitempty"`
@@ -41,6 +40,13 @@ type SubscriptionService struct {
 
 const SubscriptionPrefix = "SUBSCRIPTION#"
 
+// Values of RecurringType, which its oneof rule lists too.
+const (
+	RecurringDaily   = "daily"
+	RecurringWeekly  = "weekly"
+	RecurringMonthly = "monthly"
+)
+
 func NewSubscriptionService(settings *Settings.Settings) (*SubscriptionService, error) {
 	return &SubscriptionService{
 		db:               settings.Dynamo.Cli,
@@ -77,6 +83,7 @@ func (s *SubscriptionService) Create(consumerId string, in *Subscription) (*Subs
 	if err != nil {
 		return nil, err
 	}
+	Metrics.SubscriptionsCreated.Inc()
 
 	return in, nil
 }
@@ -108,13 +115,13 @@ func (s *SubscriptionService) Read(subscriptionId string) (*Subscription, error)
 		return nil, err
 	}
 	if len(data) != 1 {
-		return nil, errors.New(fmt.Sprintf("length of return subscriptions not 1 got %v", len(data)))
+		return nil, Utils.NotFoundOrAmbiguous("subscription", subscriptionId, len(data))
 	}
 
 	return &data[0], nil
 }
 
-func (s *SubscriptionService) Update(in *Subscription) (*Subscription, error) {
+func (s *SubscriptionService) Update(in *Subscription, ifVersion *int64) (*Subscription, error) {
 
 	prevSubscription, err := s.Read(in.SK)
 	if err != nil {
@@ -129,10 +136,7 @@ func (s *SubscriptionService) Update(in *Subscription) (*Subscription, error) {
 		return nil, err
 	}
 
-	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
-		Item:      subscription,
-		TableName: s.dynamodbSettings.TableName,
-	})
+	err = s.dynamodbSettings.PutVersioned(subscription, ifVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -149,6 +153,39 @@ func (s *SubscriptionService) List() ([]*Subscription, error) {
 
 	keyFilter := expression.Key("PK").Equal(expression.Value(SubscriptionPrefix))
 
+	filter := expression.Name("IsDeleted").NotEqual(expression.Value(true))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).WithFilter(filter).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.db.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:                 s.dynamodbSettings.TableName,
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeValues: expr.Values(),
+		ExpressionAttributeNames:  expr.Names(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data []*Subscription
+	err = attributevalue.UnmarshalListOfMaps(out.Items, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ListForConsumer returns every subscription placed by the given consumer.
+func (s *SubscriptionService) ListForConsumer(consumerId string) ([]*Subscription, error) {
+
+	keyFilter := expression.Key("PK").Equal(expression.Value(SubscriptionPrefix)).
+		And(expression.Key("SK").BeginsWith(consumerId))
+
 	expr, err := expression.NewBuilder().WithKeyCondition(keyFilter).Build()
 	if err != nil {
 		return nil, err
@@ -174,8 +211,52 @@ func (s *SubscriptionService) List() ([]*Subscription, error) {
 	return data, nil
 }
 
-func (s *SubscriptionService) Delete(subscriptionId string) (*Subscription, error) {
-	return nil, nil
+func (s *SubscriptionService) Delete(subscriptionId string, ifVersion *int64) (*Subscription, error) {
+	subscription, err := s.Read(subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.IsDeleted = true
+	subscription.SetLastModifiedNow()
+
+	data, err := attributevalue.MarshalMap(subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.dynamodbSettings.PutVersioned(data, ifVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// Erase strips the denormalized creator details and note from the subscription and
+// tombstones it.
+func (s *SubscriptionService) Erase(in *Subscription) (*Subscription, error) {
+	in.CreatedByName = ""
+	in.CreatedByUserEmail = ""
+	in.CreatedByUserPicture = ""
+	in.Note = ""
+	in.IsDeleted = true
+	in.SetLastModifiedNow()
+
+	subscription, err := attributevalue.MarshalMap(in)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      subscription,
+		TableName: s.dynamodbSettings.TableName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return in, nil
 }
 
 func (s *SubscriptionService) ConsumerCheck(consumerId string) error {
@@ -204,7 +285,7 @@ func (s *SubscriptionService) ConsumerCheck(consumerId string) error {
 		return err
 	}
 	if len(data) != 1 {
-		return errors.New(fmt.Sprintf("length of return subscriptions not 1 got %v", len(data)))
+		return Utils.NotFoundOrAmbiguous("consumer", consumerId, len(data))
 	}
 	return nil
 }
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/jonathanpatta/apartmentservices/Idempotency"
 	"github.com/jonathanpatta/apartmentservices/Middleware"
 	"github.com/jonathanpatta/apartmentservices/Settings"
+	"github.com/jonathanpatta/apartmentservices/Utils"
 	"log"
 	"net/http"
 )
@@ -27,9 +29,9 @@ func NewSubscriptionHttpService(settings *Settings.Settings) (*SubscriptionHttpS
 
 func (s *SubscriptionHttpService) Create(w http.ResponseWriter, r *http.Request) {
 	var data Subscription
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
@@ -42,19 +44,19 @@ func (s *SubscriptionHttpService) Create(w http.ResponseWriter, r *http.Request)
 
 	subscription, err := s.service.Create(consumerId, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(subscription)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -66,72 +68,89 @@ func (s *SubscriptionHttpService) Read(w http.ResponseWriter, r *http.Request) {
 
 	subscription, err := s.service.Read(data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	if Middleware.NotModified(w, r, Utils.ETagOf(subscription)) {
 		return
 	}
 
 	outData, err := json.Marshal(subscription)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *SubscriptionHttpService) Update(w http.ResponseWriter, r *http.Request) {
 
 	var data Subscription
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
+	if err != nil {
+		Middleware.WriteError(w, r, err)
+		return
+	}
+
+	ifVersion, err := Middleware.CheckIfMatch(r, func() (*Utils.Meta, error) {
+		current, err := s.service.Read(data.SK)
+		if err != nil {
+			return nil, err
+		}
+		return &current.Meta, nil
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	subscription, err := s.service.Update(&data)
+	subscription, err := s.service.Update(&data, ifVersion)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", Utils.ETagOf(subscription))
 
 	outData, err := json.Marshal(subscription)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
 func (s *SubscriptionHttpService) Delete(w http.ResponseWriter, r *http.Request) {
 	var data string
-	err := json.NewDecoder(r.Body).Decode(&data)
+	err := Utils.DecodeAndValidate(r.Body, &data)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	subscription, err := s.service.Delete(data)
+	subscription, err := s.service.Delete(data, nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	outData, err := json.Marshal(subscription)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
 	_, err = fmt.Fprint(w, string(outData))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 	}
 }
 
@@ -139,20 +158,15 @@ func (s *SubscriptionHttpService) List(w http.ResponseWriter, r *http.Request) {
 
 	subscription, err := s.service.List()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Middleware.WriteError(w, r, err)
 		return
 	}
 
-	outData, err := json.Marshal(subscription)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if Middleware.NotModified(w, r, Utils.ETagOf(subscription)) {
 		return
 	}
 
-	_, err = fmt.Fprint(w, string(outData))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	Middleware.WriteJson(w, r, http.StatusOK, subscription)
 }
 
 func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
@@ -162,10 +176,15 @@ func AddSubrouter(r *mux.Router, settings *Settings.Settings) {
 	}
 	router := r.PathPrefix("/subscription").Subrouter()
 
+	idempotency, err := Idempotency.NewIdempotencyService(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	router.Use(settings.MiddlewareService.ValidateToken)
 
 	router.HandleFunc("/list", server.List).Methods("GET", "OPTIONS")
-	router.HandleFunc("/create/{consumerId}", server.Create).Methods("POST", "OPTIONS")
+	router.HandleFunc("/create/{consumerId}", idempotency.Handler(server.Create)).Methods("POST", "OPTIONS")
 	router.HandleFunc("/update", server.Update).Methods("POST", "OPTIONS")
 	router.HandleFunc("/delete", server.Delete).Methods("POST", "OPTIONS")
 	router.HandleFunc("/{subscriptionId}", server.Read).Methods("GET", "OPTIONS")
@@ -0,0 +1,88 @@
+package Utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ErrorCode string
+
+const (
+	BadRequest   ErrorCode = "bad_request"
+	Validation   ErrorCode = "validation_failed"
+	Unauthorized ErrorCode = "unauthorized"
+	Forbidden    ErrorCode = "forbidden"
+	NotFound     ErrorCode = "not_found"
+	Conflict     ErrorCode = "conflict"
+	Internal     ErrorCode = "internal"
+
+	// PreconditionFailed rejects writes made against a stale ETag.
+	PreconditionFailed ErrorCode = "precondition_failed"
+	// Unprocessable rejects a well formed request that cannot be applied,
+	// such as an idempotency key reused with a different payload.
+	Unprocessable ErrorCode = "unprocessable"
+)
+
+// Error is a domain error the http layer knows how to report to clients.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	// Fields maps the json name of each invalid input field to what is wrong
+	// with it.
+	Fields map[string]string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NewError(code ErrorCode, format string, a ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, a...),
+	}
+}
+
+func WrapError(code ErrorCode, err error) *Error {
+	return &Error{
+		Code: code,
+		Err:  err,
+	}
+}
+
+// ErrorFieldsOf returns the invalid fields reported by the first Error in
+// err's chain.
+func ErrorFieldsOf(err error) map[string]string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Fields
+	}
+	return nil
+}
+
+// ErrorCodeOf returns the code of the first Error in err's chain, or
+// Internal when there is none.
+func ErrorCodeOf(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return Internal
+}
+
+// NotFoundOrAmbiguous is returned by single record reads that did not get
+// exactly one match.
+func NotFoundOrAmbiguous(kind string, id string, count int) error {
+	if count == 0 {
+		return NewError(NotFound, "%v %v not found", kind, id)
+	}
+	return NewError(Internal, "expected one %v %v got %v", kind, id, count)
+}
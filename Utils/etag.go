@@ -0,0 +1,38 @@
+package Utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+)
+
+type metaHolder interface {
+	GetMeta() *Meta
+}
+
+// ETagOf returns the strong ETag of a record embedding Meta, or of a slice of
+// them, derived from each record's key, Version and LastModified. It returns
+// "" for anything else.
+func ETagOf(v interface{}) string {
+	if holder, ok := v.(metaHolder); ok {
+		if reflect.ValueOf(v).IsNil() {
+			return ""
+		}
+		return holder.GetMeta().ETag()
+	}
+
+	list := reflect.ValueOf(v)
+	if list.Kind() != reflect.Slice {
+		return ""
+	}
+	hash := sha256.New()
+	for i := 0; i < list.Len(); i++ {
+		holder, ok := list.Index(i).Interface().(metaHolder)
+		if !ok {
+			return ""
+		}
+		meta := holder.GetMeta()
+		hash.Write([]byte(meta.SK + meta.ETag() + "\n"))
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
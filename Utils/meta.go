@@ -2,6 +2,7 @@ package Utils
 
 import (
 	"github.com/google/uuid"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -12,11 +13,25 @@ type Meta struct {
 	CreatedAt    int64  `json:"created_at,omitempty"`
 	LastModified int64  `json:"last_modified,omitempty"`
 	IsDeleted    bool   `json:"is_deleted,omitempty"`
+	// Version counts the writes to the record.
+	Version int64 `json:"version,omitempty"`
 }
 
+// SetLastModifiedNow marks a write to the record, bumping its Version.
 func (s *Meta) SetLastModifiedNow() {
 	now := time.Now().Unix()
 	s.LastModified = now
+	s.Version++
+}
+
+// ETag is a strong entity tag for this revision of the record.
+func (s *Meta) ETag() string {
+	return `"` + strconv.FormatInt(s.Version, 10) + "-" + strconv.FormatInt(s.LastModified, 10) + `"`
+}
+
+// GetMeta gives access to the Meta of any record embedding it.
+func (s *Meta) GetMeta() *Meta {
+	return s
 }
 func (s *Meta) SetCreatedAtNow() {
 	now := time.Now().Unix()
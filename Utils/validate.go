@@ -0,0 +1,163 @@
+package Utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate checks v against the rules in its `validate` struct tags and
+// returns a Validation error listing every failing field.
+//
+// Supported rules are required, min=N and max=N (the value for numbers, the
+// length for strings and slices), oneof=a b c and url, an http or https url. Fields are reported by
+// their json name. Slices of structs are validated element by element.
+func Validate(v interface{}) error {
+	fields := map[string]string{}
+	validateValue(reflect.ValueOf(v), "", fields)
+	if len(fields) == 0 {
+		return nil
+	}
+	return &Error{
+		Code:    Validation,
+		Message: "invalid input",
+		Fields:  fields,
+	}
+}
+
+// DecodeAndValidate decodes a json body into v, rejecting unknown fields, and
+// validates the result.
+func DecodeAndValidate(body io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		return WrapError(BadRequest, err)
+	}
+	return Validate(v)
+}
+
+func validateValue(v reflect.Value, prefix string, fields map[string]string) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%v[%v].", prefix, i), fields)
+		}
+	case reflect.Struct:
+		validateStruct(v, prefix, fields)
+	}
+}
+
+func validateStruct(v reflect.Value, prefix string, fields map[string]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Anonymous {
+			validateValue(value, prefix, fields)
+			continue
+		}
+
+		rules := field.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		name = prefix + name
+
+		for _, rule := range strings.Split(rules, ",") {
+			msg := checkRule(rule, value)
+			if msg != "" {
+				fields[name] = msg
+				break
+			}
+		}
+	}
+}
+
+func checkRule(rule string, v reflect.Value) string {
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("has invalid rule %v", rule)
+		}
+		size, unit := measure(v)
+		if name == "min" && size < limit {
+			if unit != "" {
+				return fmt.Sprintf("must have at least %v %v", limit, unit)
+			}
+			return fmt.Sprintf("must be at least %v", limit)
+		}
+		if name == "max" && size > limit {
+			if unit != "" {
+				return fmt.Sprintf("must have at most %v %v", limit, unit)
+			}
+			return fmt.Sprintf("must be at most %v", limit)
+		}
+	case "oneof":
+		if v.Kind() != reflect.String || v.String() == "" {
+			return ""
+		}
+		options := strings.Fields(arg)
+		for _, option := range options {
+			if v.String() == option {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(options, ", ")
+	case "url":
+		if v.Kind() != reflect.String || v.String() == "" {
+			return ""
+		}
+		u, err := url.Parse(v.String())
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an http or https url such as http://localhost:8000"
+		}
+	}
+	return ""
+}
+
+// measure returns the number a min or max rule compares against and, for
+// lengths, what is being counted.
+func measure(v reflect.Value) (int64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return int64(utf8.RuneCountInString(v.String())), "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return int64(v.Len()), "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return int64(v.Float()), ""
+	}
+	return 0, ""
+}
@@ -0,0 +1,140 @@
+package Utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckRule(t *testing.T) {
+	tests := []struct {
+		rule  string
+		value interface{}
+		want  string
+	}{
+		{"required", "", "is required"},
+		{"required", "a", ""},
+		{"required", 0, "is required"},
+		{"required", []string{}, ""},
+		{"min=0", 0, ""},
+		{"min=0", -1, "must be at least 0"},
+		{"max=100", 100, ""},
+		{"max=100", 101, "must be at most 100"},
+		{"min=2", "é", "must have at least 2 characters"},
+		{"max=2", "éé", ""},
+		{"max=1", []string{"a", "b"}, "must have at most 1 items"},
+		{"min=x", 1, "has invalid rule min=x"},
+		{"oneof=daily weekly monthly", "weekly", ""},
+		{"oneof=daily weekly monthly", "yearly", "must be one of daily, weekly, monthly"},
+		{"oneof=daily weekly monthly", "", ""},
+		{"url", "https://example.com", ""},
+		{"url", "http://localhost:8000", ""},
+		{"url", "ftp://example.com", "must be an http or https url such as http://localhost:8000"},
+		{"url", "localhost:8000", "must be an http or https url such as http://localhost:8000"},
+		{"url", "", ""},
+		{"unknown", "", ""},
+	}
+	for _, test := range tests {
+		if got := checkRule(test.rule, reflect.ValueOf(test.value)); got != test.want {
+			t.Errorf("checkRule(%q, %#v) = %q, want %q", test.rule, test.value, got, test.want)
+		}
+	}
+}
+
+type validated struct {
+	Percentage int     `json:"percentage" validate:"min=0,max=100"`
+	Recurring  string  `json:"recurring,omitempty" validate:"required,oneof=daily weekly"`
+	Endpoint   string  `validate:"url"`
+	Children   []child `json:"children" validate:"max=2"`
+	Untagged   string  `json:"untagged"`
+	embedded
+}
+
+type child struct {
+	Name string `json:"name" validate:"required,max=3"`
+}
+
+type embedded struct {
+	Note string `json:"note" validate:"max=1"`
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		value validated
+		want  map[string]string
+	}{
+		{"valid", validated{Percentage: 100, Recurring: "daily"}, nil},
+		{"below min", validated{Percentage: -1, Recurring: "daily"}, map[string]string{
+			"percentage": "must be at least 0",
+		}},
+		{"above max", validated{Percentage: 101, Recurring: "daily"}, map[string]string{
+			"percentage": "must be at most 100",
+		}},
+		{"first failing rule wins", validated{}, map[string]string{
+			"recurring": "is required",
+		}},
+		{"oneof", validated{Recurring: "hourly"}, map[string]string{
+			"recurring": "must be one of daily, weekly",
+		}},
+		{"untagged name", validated{Recurring: "daily", Endpoint: "nope"}, map[string]string{
+			"Endpoint": "must be an http or https url such as http://localhost:8000",
+		}},
+		{"embedded", validated{
+			Recurring: "weekly",
+			Children:  []child{{Name: "a"}, {}, {Name: "abcd"}},
+			embedded:  embedded{Note: "ab"},
+		}, map[string]string{
+			"children": "must have at most 2 items",
+			"note":     "must have at most 1 characters",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Validate(&test.value)
+			if test.want == nil {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			var invalid *Error
+			if !errors.As(err, &invalid) || invalid.Code != Validation {
+				t.Fatalf("Validate = %v, want a validation error", err)
+			}
+			if !reflect.DeepEqual(invalid.Fields, test.want) {
+				t.Errorf("Fields = %v, want %v", invalid.Fields, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateSlice(t *testing.T) {
+	err := Validate([]*child{{Name: "a"}, {}, nil, {Name: "abcd"}})
+	var invalid *Error
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Validate = %v, want a validation error", err)
+	}
+	want := map[string]string{
+		"[1].name": "is required",
+		"[3].name": "must have at most 3 characters",
+	}
+	if !reflect.DeepEqual(invalid.Fields, want) {
+		t.Errorf("Fields = %v, want %v", invalid.Fields, want)
+	}
+}
+
+func TestDecodeAndValidate(t *testing.T) {
+	var v validated
+	err := DecodeAndValidate(strings.NewReader(`{"percentage": 5, "extra": 1}`), &v)
+	var invalid *Error
+	if !errors.As(err, &invalid) || invalid.Code != BadRequest {
+		t.Errorf("unknown field: got %v, want a bad request", err)
+	}
+
+	err = DecodeAndValidate(strings.NewReader(`{"percentage": 500, "recurring": "daily"}`), &v)
+	if !errors.As(err, &invalid) || invalid.Code != Validation {
+		t.Errorf("invalid value: got %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/google/uuid"
+	"github.com/jonathanpatta/apartmentservices/Lambda"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Router"
+	"net/http"
+	"os"
+)
+
+// lambdalocal runs the handler behind lambda.go in-process, to reproduce
+// what the deployed function does without deploying it. It either invokes
+// the handler with event files and prints each response:
+//
+//	go run lambdalocal.go Lambda/events/apigateway-v2.json
+//
+// or serves http, turning every request into an event of the given source:
+//
+//	go run lambdalocal.go -serve :8001 -source apigateway-v1
+func main() {
+	serve := flag.String("serve", "", "address to serve http on, translating requests into events")
+	source := flag.String("source", Lambda.SourceAPIGatewayV1, "event source to emulate when serving: apigateway-v1, apigateway-v2 or alb")
+	flag.Parse()
+
+	if *serve == "" && flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: go run lambdalocal.go [-serve addr [-source name]] [event.json ...]")
+		os.Exit(2)
+	}
+
+	handler := Lambda.NewHandler(Router.GetMainRouter())
+
+	failed := false
+	for _, name := range flag.Args() {
+		payload, err := os.ReadFile(name)
+		if err == nil {
+			payload, err = invoke(handler, payload)
+		}
+		if err != nil {
+			Logger.Default.Error("invocation failed", "event", name, "error", err)
+			failed = true
+			continue
+		}
+		var out bytes.Buffer
+		json.Indent(&out, payload, "", "  ")
+		fmt.Printf("%v\n%v\n", name, out.String())
+	}
+
+	if *serve != "" {
+		Logger.Default.Info("emulating lambda", "addr", *serve, "source", *source)
+		err := http.ListenAndServe(*serve, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			event, err := Lambda.NewEvent(*source, r)
+			if err == nil {
+				event, err = invoke(handler, event)
+			}
+			if err == nil {
+				err = Lambda.WriteResponse(w, event)
+			}
+			if err != nil {
+				// The function failing is a 502 from API Gateway and ALB.
+				Logger.Default.Error("invocation failed", "error", err)
+				http.Error(w, err.Error(), http.StatusBadGateway)
+			}
+		}))
+		Logger.Default.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// invoke calls the handler the way the lambda runtime does, with the raw
+// event and a lambda context.
+func invoke(handler *Lambda.Handler, payload []byte) ([]byte, error) {
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{
+		AwsRequestID:       uuid.New().String(),
+		InvokedFunctionArn: "arn:aws:lambda:local:000000000000:function:apartment-services",
+	})
+	return handler.Invoke(ctx, payload)
+}
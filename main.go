@@ -1,16 +1,135 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"flag"
+	"github.com/jonathanpatta/apartmentservices/Grpc"
+	"github.com/jonathanpatta/apartmentservices/Health"
+	"github.com/jonathanpatta/apartmentservices/Logger"
+	"github.com/jonathanpatta/apartmentservices/Metrics"
+	"github.com/jonathanpatta/apartmentservices/Middleware"
 	"github.com/jonathanpatta/apartmentservices/Router"
+	"github.com/jonathanpatta/apartmentservices/Settings"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	router := Router.GetMainRouter()
-	http.Handle("/", router)
-	err := http.ListenAndServe(":8000", nil)
+	flags := Settings.NewConfigFlags(flag.CommandLine)
+	flag.Parse()
+
+	serverSettings, err := Settings.LoadServerSettings(flags)
+	if err != nil {
+		Logger.Default.Error("unable to load server settings", "error", err)
+		os.Exit(1)
+	}
+
+	// A bad configuration will not fix itself, so it fails the start up
+	// rather than leaving the service up and unavailable.
+	config, err := Settings.LoadConfig(flags)
+	if err != nil {
+		Logger.Default.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	var router http.Handler
+	var grpcServer *grpc.Server
+	settings, err := Settings.NewSettingsFromConfig(config)
+	if err != nil {
+		Logger.Default.Error("unable to load settings", "error", err)
+		router = Health.NewUnavailableRouter(err)
+	} else {
+		router = Router.NewRouter(settings)
+		if serverSettings.GrpcAddr != "" {
+			grpcServer, err = Grpc.NewServer(settings)
+			if err != nil {
+				Logger.Default.Error("unable to create grpc server", "error", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	mux := http.NewServeMux()
+	// Compression is left out of the lambda, where API Gateway REST APIs
+	// would pass compressed bodies on as base64 text unless */* is set up as
+	// a binary media type; API Gateway and CloudFront compress there instead.
+	mux.Handle("/", Middleware.CompressionMiddleware(Middleware.MaxBodyMiddleware(serverSettings.MaxBodyBytes)(router)))
+	mux.Handle("/metrics", Metrics.Handler())
+
+	server := &http.Server{
+		Addr:              serverSettings.Addr,
+		Handler:           mux,
+		ReadTimeout:       serverSettings.ReadTimeout,
+		ReadHeaderTimeout: serverSettings.ReadHeaderTimeout,
+		WriteTimeout:      serverSettings.WriteTimeout,
+		IdleTimeout:       serverSettings.IdleTimeout,
+		MaxHeaderBytes:    serverSettings.MaxHeaderBytes,
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		Logger.Default.Info("server listening", "addr", server.Addr, "tls", serverSettings.TLSCertFile != "")
+		if serverSettings.TLSCertFile != "" {
+			stopped <- server.ListenAndServeTLS(serverSettings.TLSCertFile, serverSettings.TLSKeyFile)
+		} else {
+			stopped <- server.ListenAndServe()
+		}
+	}()
+
+	if grpcServer != nil {
+		listener, err := net.Listen("tcp", serverSettings.GrpcAddr)
+		if err != nil {
+			Logger.Default.Error("unable to listen for grpc", "error", err)
+			os.Exit(1)
+		}
+		go func() {
+			Logger.Default.Info("grpc server listening", "addr", serverSettings.GrpcAddr)
+			stopped <- grpcServer.Serve(listener)
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	select {
+	case err = <-stopped:
+		Logger.Default.Error("server stopped", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+
+	Logger.Default.Info("shutting down", "grace_period", serverSettings.ShutdownGracePeriod.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverSettings.ShutdownGracePeriod)
+	defer cancel()
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
+		}
+		close(grpcStopped)
+	}()
+
+	err = server.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		Logger.Default.Warn("grace period over, closing remaining connections")
+		err = server.Close()
+	}
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+	}
 	if err != nil {
-		fmt.Println(err)
+		Logger.Default.Error("shutdown failed", "error", err)
+		os.Exit(1)
 	}
+	Logger.Default.Info("server stopped")
 }